@@ -19,10 +19,31 @@ import (
 	"log"
 )
 
+// ErrorLogger is the part of logs.Logger the error helpers need. It's
+// declared here rather than imported since the logs package builds on calls.
+type ErrorLogger interface {
+	Error(msg string, args ...interface{})
+}
+
+// StandardLogger reports errors through the standard library log package
+type StandardLogger struct{}
+
+func (StandardLogger) Error(msg string, args ...interface{}) {
+	log.Printf(msg, args...)
+}
+
+// DefaultLogger is used by LogErrors and Checked, replace it with a
+// logs.Logger to have their output leveled and structured
+var DefaultLogger ErrorLogger = StandardLogger{}
+
 func LogErrors(op func() error) {
+	LogErrorsWithLogger(DefaultLogger, op)
+}
+
+func LogErrorsWithLogger(logger ErrorLogger, op func() error) {
 	err := op()
 	if err != nil {
-		log.Println(err)
+		logger.Error("%s", err)
 	}
 }
 
@@ -30,6 +51,10 @@ func Checked(op func() error) func() {
 	return func() { LogErrors(op) }
 }
 
+func CheckedWithLogger(logger ErrorLogger, op func() error) func() {
+	return func() { LogErrorsWithLogger(logger, op) }
+}
+
 func CheckFatal(err error) {
 	if err != nil {
 		panic(err)
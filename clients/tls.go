@@ -18,14 +18,16 @@ package clients
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"github.com/threeguys/golang-toolkit/logs"
 	"github.com/threeguys/golang-toolkit/servers"
 	"io/ioutil"
-	"log"
 	"net/http"
 )
 
+var logger = logs.GetLogger("clients.tls")
+
 func NewClientMutualTlsConfig(cb *servers.CertificateBundle) (*tls.Config, error) {
-	log.Println("Opening root cert", cb.RootPath)
+	logger.With("path", cb.RootPath).Info("Opening root cert")
 	caCert, err := ioutil.ReadFile(cb.RootPath)
 	if err != nil {
 		return nil, err
@@ -34,7 +36,7 @@ func NewClientMutualTlsConfig(cb *servers.CertificateBundle) (*tls.Config, error
 	caCertPool := x509.NewCertPool()
 	caCertPool.AppendCertsFromPEM(caCert)
 
-	log.Println("Opening keypair", cb.CertPath, cb.KeyPath)
+	logger.With("cert", cb.CertPath, "key", cb.KeyPath).Info("Opening keypair")
 	cert, err := tls.LoadX509KeyPair(cb.CertPath, cb.KeyPath)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,202 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what an AsyncLogger does when its queue is full
+type OverflowPolicy int
+
+const (
+	// BlockWhenFull makes the caller wait for room in the queue
+	BlockWhenFull OverflowPolicy = iota
+	// DropOldest discards the entry at the head of the queue to make room
+	DropOldest
+	// DropNewest discards the entry being logged
+	DropNewest
+)
+
+// AsyncLogger queues entries and writes them to its target on a single
+// background goroutine, so callers never wait on a slow writer unless the
+// policy is BlockWhenFull and the queue is full. Entries logged after Close
+// are written synchronously.
+type AsyncLogger struct {
+	target Logger
+	policy OverflowPolicy
+	queue  chan *Entry
+	done   chan struct{}
+
+	closeLock sync.RWMutex
+	closed    bool
+
+	pendingLock sync.Mutex
+	drained     *sync.Cond
+	pending     int
+
+	dropped uint64
+}
+
+func NewAsyncLogger(target Logger, size int, policy OverflowPolicy) *AsyncLogger {
+	al := &AsyncLogger{
+		target: target,
+		policy: policy,
+		queue:  make(chan *Entry, size),
+		done:   make(chan struct{}),
+	}
+	al.drained = sync.NewCond(&al.pendingLock)
+
+	go al.run()
+	return al
+}
+
+func (al *AsyncLogger) run() {
+	defer close(al.done)
+	for e := range al.queue {
+		forward(al.target, e)
+		al.finished()
+	}
+}
+
+func (al *AsyncLogger) finished() {
+	al.pendingLock.Lock()
+	defer al.pendingLock.Unlock()
+
+	al.pending--
+	if al.pending == 0 {
+		al.drained.Broadcast()
+	}
+}
+
+func (al *AsyncLogger) Log(level LogLevels, msg string, args ...interface{}) {
+	if !al.target.Enabled(level) {
+		return
+	}
+	reportError(al.LogEntry(newEntry(0, level, nil, msg, args)))
+}
+
+func (al *AsyncLogger) LogEntry(e *Entry) error {
+	al.closeLock.RLock()
+	defer al.closeLock.RUnlock()
+
+	if al.closed {
+		forward(al.target, e)
+		return nil
+	}
+
+	al.pendingLock.Lock()
+	al.pending++
+	al.pendingLock.Unlock()
+
+	switch al.policy {
+	case DropNewest:
+		select {
+		case al.queue <- e:
+		default:
+			al.drop()
+		}
+
+	case DropOldest:
+		for {
+			select {
+			case al.queue <- e:
+				return nil
+			default:
+			}
+
+			select {
+			case <-al.queue:
+				al.drop()
+			default:
+			}
+		}
+
+	default:
+		al.queue <- e
+	}
+	return nil
+}
+
+func (al *AsyncLogger) drop() {
+	atomic.AddUint64(&al.dropped, 1)
+	al.finished()
+}
+
+// Dropped returns how many entries have been discarded because the queue was full
+func (al *AsyncLogger) Dropped() uint64 {
+	return atomic.LoadUint64(&al.dropped)
+}
+
+// Pending returns how many entries are queued or being written
+func (al *AsyncLogger) Pending() int {
+	al.pendingLock.Lock()
+	defer al.pendingLock.Unlock()
+	return al.pending
+}
+
+// Flush waits until every entry queued so far has been written or dropped
+func (al *AsyncLogger) Flush() {
+	al.pendingLock.Lock()
+	defer al.pendingLock.Unlock()
+	for al.pending > 0 {
+		al.drained.Wait()
+	}
+}
+
+// Close drains the queue and stops the background goroutine
+func (al *AsyncLogger) Close() error {
+	al.closeLock.Lock()
+	if al.closed {
+		al.closeLock.Unlock()
+		return nil
+	}
+	al.closed = true
+	close(al.queue)
+	al.closeLock.Unlock()
+
+	<-al.done
+	return nil
+}
+
+func (al *AsyncLogger) Info(msg string, args ...interface{}) {
+	al.Log(INFO, msg, args...)
+}
+
+func (al *AsyncLogger) Warn(msg string, args ...interface{}) {
+	al.Log(WARN, msg, args...)
+}
+
+func (al *AsyncLogger) Debug(msg string, args ...interface{}) {
+	al.Log(DEBUG, msg, args...)
+}
+
+func (al *AsyncLogger) Error(msg string, args ...interface{}) {
+	al.Log(ERROR, msg, args...)
+}
+
+func (al *AsyncLogger) Enabled(level LogLevels) bool {
+	return al.target.Enabled(level)
+}
+
+func (al *AsyncLogger) With(keysAndValues ...interface{}) Logger {
+	return al.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (al *AsyncLogger) WithFields(fields Fields) Logger {
+	return withFields(al, fields)
+}
@@ -0,0 +1,94 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// gatedWriter holds up the first write until release is closed so tests can
+// fill an async queue deterministically
+type gatedWriter struct {
+	lock    sync.Mutex
+	lines   []string
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (g *gatedWriter) logger() logs.Logger {
+	return logs.WriterLogger{Writer: func(data []byte) error {
+		g.once.Do(func() { close(g.started) })
+		<-g.release
+
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		g.lines = append(g.lines, strings.TrimSpace(string(data)))
+		return nil
+	}}
+}
+
+func TestAsyncLogger_DropPolicies(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+
+	for policy, expected := range map[logs.OverflowPolicy][]string{
+		logs.DropNewest: {"[INFO] - 0", "[INFO] - 1", "[INFO] - 2"},
+		logs.DropOldest: {"[INFO] - 0", "[INFO] - 3", "[INFO] - 4"},
+	} {
+		gw := newGatedWriter()
+		al := logs.NewAsyncLogger(gw.logger(), 2, policy)
+
+		al.Info("0")
+		<-gw.started
+		for _, msg := range []string{"1", "2", "3", "4"} {
+			al.Info(msg)
+		}
+		assert.Equal(uint64(2), al.Dropped())
+
+		close(gw.release)
+		al.Flush()
+		assert.Equal(0, al.Pending())
+		assert.Equal(expected, gw.lines)
+		assert.Nil(al.Close())
+	}
+}
+
+func TestAsyncLogger_Close(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	gw := newGatedWriter()
+	close(gw.release)
+
+	al := logs.NewAsyncLogger(gw.logger(), 10, logs.BlockWhenFull)
+	log := al.With("id", 1)
+	for i := 0; i < 5; i++ {
+		log.Info("queued")
+	}
+	assert.Nil(al.Close())
+	assert.Equal(5, len(gw.lines))
+	assert.Equal("[INFO] - queued id=1", gw.lines[4])
+
+	al.Warn("after close")
+	assert.Equal(6, len(gw.lines))
+	assert.Equal(uint64(0), al.Dropped())
+}
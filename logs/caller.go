@@ -0,0 +1,215 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"fmt"
+	"github.com/threeguys/golang-toolkit/calls"
+	"github.com/threeguys/golang-toolkit/objects"
+	"log"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const maxStackDepth = 64
+
+var callerEnabled int32
+var errorStacks int32 = 1
+
+var skipLock sync.RWMutex
+var skippedPackages = map[string]bool{
+	reflect.TypeOf(SimpleLogger{}).PkgPath():         true,
+	reflect.TypeOf(calls.StandardLogger{}).PkgPath(): true,
+	reflect.TypeOf(objects.Assertions{}).PkgPath():   true,
+	reflect.TypeOf(log.Logger{}).PkgPath():           true,
+}
+
+// SetCallerEnabled turns recording the file, line and function of every
+// entry on or off. It's off by default since it costs a stack walk per entry.
+func SetCallerEnabled(enabled bool) {
+	atomic.StoreInt32(&callerEnabled, boolFlag(enabled))
+}
+
+func CallerEnabled() bool {
+	return atomic.LoadInt32(&callerEnabled) == 1
+}
+
+// SetErrorStacks controls whether entries logged at ERROR with an error
+// among their arguments or fields get a stack trace attached. It's on by
+// default.
+func SetErrorStacks(enabled bool) {
+	atomic.StoreInt32(&errorStacks, boolFlag(enabled))
+}
+
+func ErrorStacks() bool {
+	return atomic.LoadInt32(&errorStacks) == 1
+}
+
+func boolFlag(enabled bool) int32 {
+	if enabled {
+		return 1
+	}
+	return 0
+}
+
+// SkipCallerPackage treats every function in the package at path as part of
+// the logging machinery, so the caller reported is whoever called into it.
+// The logs package itself, the calls and objects helpers and the standard
+// log package are skipped already, which is how calls.LogErrors reports the
+// line that called it, even through RedirectStandardLog.
+func SkipCallerPackage(path string) {
+	skipLock.Lock()
+	defer skipLock.Unlock()
+	skippedPackages[path] = true
+}
+
+func isSkippedPackage(function string) bool {
+	skipLock.RLock()
+	defer skipLock.RUnlock()
+	return skippedPackages[framePackage(function)]
+}
+
+// framePackage extracts the import path from a fully qualified function
+// name like github.com/threeguys/golang-toolkit/logs.(*SimpleLogger).Log
+func framePackage(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+// AddCallerSkip returns a logger that reports the caller n frames further up
+// the stack than it normally would, for wrappers outside the packages
+// skipped by SkipCallerPackage
+func AddCallerSkip(logger Logger, n int) Logger {
+	switch l := logger.(type) {
+	case *SimpleLogger:
+		skipped := *l
+		skipped.skip += n
+		return &skipped
+	case *fieldLogger:
+		skipped := *l
+		skipped.skip += n
+		return &skipped
+	}
+	return &fieldLogger{target: logger, skip: n}
+}
+
+func (c *Caller) String() string {
+	return fmt.Sprintf("%s:%d", shortPath(c.File), c.Line)
+}
+
+// shortPath trims a file down to its directory and name
+func shortPath(path string) string {
+	dir, file := filepath.Split(filepath.ToSlash(path))
+	if dir = strings.TrimSuffix(dir, "/"); len(dir) > 0 {
+		return filepath.Base(dir) + "/" + file
+	}
+	return file
+}
+
+// newEntry formats a message into an entry, evaluating any lazy arguments and
+// attaching the caller and stack when they're enabled. skip is the number of
+// frames to skip beyond the ones in skipped packages.
+func newEntry(skip int, level LogLevels, fields Fields, msg string, args []interface{}) *Entry {
+	args = resolveArgs(args)
+	e := &Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: fmt.Sprintf(msg, args...),
+		format:  msg,
+	}
+
+	withCaller := CallerEnabled()
+	withStack := level == ERROR && ErrorStacks() && (containsError(args) || fieldsContainError(fields))
+	if withCaller || withStack {
+		frames := callerFrames(skip)
+		if len(frames) > 0 && withCaller {
+			e.Caller = &Caller{File: frames[0].File, Line: frames[0].Line, Function: frames[0].Function}
+		}
+		if withStack {
+			e.Stack = formatStack(frames)
+		}
+	}
+	return e
+}
+
+func containsError(args []interface{}) bool {
+	for _, arg := range args {
+		if _, ok := arg.(error); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldsContainError(fields Fields) bool {
+	for _, v := range fields {
+		if _, ok := v.(error); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// callerFrames returns the stack starting at the first frame outside the
+// skipped packages, less another skip frames
+func callerFrames(skip int) []runtime.Frame {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []runtime.Frame
+	inLogger := true
+	for {
+		frame, more := frames.Next()
+		if inLogger && !isSkippedPackage(frame.Function) {
+			inLogger = false
+		}
+
+		if !inLogger {
+			if skip > 0 {
+				skip--
+			} else {
+				stack = append(stack, frame)
+			}
+		}
+
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+func formatStack(frames []runtime.Frame) string {
+	var sb strings.Builder
+	for _, frame := range frames {
+		sb.WriteString(frame.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(frame.File)
+		sb.WriteString(":")
+		sb.WriteString(fmt.Sprintf("%d", frame.Line))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
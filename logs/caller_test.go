@@ -0,0 +1,102 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"errors"
+	"github.com/threeguys/golang-toolkit/calls"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func currentLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func logThroughWrapper(logger logs.Logger) {
+	logger.Info("wrapped")
+}
+
+func TestCaller(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	capture := logs.NewCaptureLogger()
+	previous := logs.GetRootLogger()
+	logs.SetRootLogger(capture)
+	defer logs.SetRootLogger(previous)
+
+	logs.SetCallerEnabled(true)
+	defer logs.SetCallerEnabled(false)
+
+	logger := logs.GetLogger("caller")
+	line := currentLine() + 1
+	logger.With("a", 1).Info("direct")
+	calls.LogErrorsWithLogger(logger, func() error { return errors.New("failed op") })
+	logThroughWrapper(logs.AddCallerSkip(logger, 1))
+	logThroughWrapper(logs.AddCallerSkip(capture, 1))
+
+	entries := capture.Entries()
+	assert.Equal(4, len(entries))
+	for i, e := range entries {
+		assert.NotNil(e.Caller)
+		assert.True(strings.HasSuffix(e.Caller.File, "/logs/caller_test.go"))
+		assert.Equal(line+i, e.Caller.Line)
+		assert.Equal("github.com/threeguys/golang-toolkit/logs_test.TestCaller", e.Caller.Function)
+	}
+	assert.True(strings.HasPrefix(entries[0].Caller.String(), "logs/caller_test.go:"))
+}
+
+func TestCaller_StandardLog(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	capture := logs.NewCaptureLogger()
+	defer logs.RedirectStandardLog(capture, logs.ERROR)()
+
+	logs.SetCallerEnabled(true)
+	defer logs.SetCallerEnabled(false)
+
+	line := currentLine() + 1
+	calls.LogErrors(func() error { return errors.New("failed op") })
+
+	entries := capture.Entries()
+	assert.Equal(1, len(entries))
+	assert.NotNil(entries[0].Caller)
+	assert.True(strings.HasSuffix(entries[0].Caller.File, "/logs/caller_test.go"))
+	assert.Equal(line, entries[0].Caller.Line)
+}
+
+func TestErrorStacks(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	capture := logs.NewCaptureLogger()
+	previous := logs.GetRootLogger()
+	logs.SetRootLogger(capture)
+	defer logs.SetRootLogger(previous)
+
+	logger := logs.GetLogger("stacks")
+	logger.Error("no error value")
+	logger.Warn("not at error: %s", errors.New("warned"))
+	logger.Error("failed: %s", errors.New("boom"))
+	logger.With("err", errors.New("boom")).Error("failed")
+
+	entries := capture.Entries()
+	assert.Equal("", entries[0].Stack)
+	assert.Equal("", entries[1].Stack)
+	assert.Nil(entries[2].Caller)
+	assert.True(strings.HasPrefix(entries[2].Stack, "github.com/threeguys/golang-toolkit/logs_test.TestErrorStacks\n"))
+	assert.True(strings.HasPrefix(entries[3].Stack, "github.com/threeguys/golang-toolkit/logs_test.TestErrorStacks\n"))
+}
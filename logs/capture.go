@@ -0,0 +1,178 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"fmt"
+	"github.com/threeguys/golang-toolkit/objects"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// CaptureLogger records every entry it receives so tests can make assertions
+// about what was logged. Install it with SetRootLogger to capture everything
+// logged through GetLogger.
+type CaptureLogger struct {
+	lock    sync.Mutex
+	entries []Entry
+}
+
+func NewCaptureLogger() *CaptureLogger {
+	return &CaptureLogger{}
+}
+
+func (c *CaptureLogger) Log(level LogLevels, msg string, args ...interface{}) {
+	if !c.Enabled(level) {
+		return
+	}
+	reportError(c.LogEntry(newEntry(0, level, nil, msg, args)))
+}
+
+func (c *CaptureLogger) LogEntry(e *Entry) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = append(c.entries, *e)
+	return nil
+}
+
+// Entries returns a copy of everything captured so far
+func (c *CaptureLogger) Entries() []Entry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entries := make([]Entry, len(c.entries))
+	copy(entries, c.entries)
+	return entries
+}
+
+// Find returns the captured entries at level whose message contains substring
+func (c *CaptureLogger) Find(level LogLevels, substring string) []Entry {
+	var found []Entry
+	for _, e := range c.Entries() {
+		if e.Level == level && strings.Contains(e.Message, substring) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+func (c *CaptureLogger) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = nil
+}
+
+func (c *CaptureLogger) Info(msg string, args ...interface{}) {
+	c.Log(INFO, msg, args...)
+}
+
+func (c *CaptureLogger) Warn(msg string, args ...interface{}) {
+	c.Log(WARN, msg, args...)
+}
+
+func (c *CaptureLogger) Debug(msg string, args ...interface{}) {
+	c.Log(DEBUG, msg, args...)
+}
+
+func (c *CaptureLogger) Error(msg string, args ...interface{}) {
+	c.Log(ERROR, msg, args...)
+}
+
+func (c *CaptureLogger) Enabled(level LogLevels) bool {
+	return meetsLevel(level, DEBUG)
+}
+
+func (c *CaptureLogger) With(keysAndValues ...interface{}) Logger {
+	return c.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (c *CaptureLogger) WithFields(fields Fields) Logger {
+	return withFields(c, fields)
+}
+
+// CaptureAssertions checks the contents of a CaptureLogger, reporting
+// failures through objects.Assertions
+type CaptureAssertions struct {
+	assert  *objects.Assertions
+	capture *CaptureLogger
+}
+
+func (c *CaptureLogger) Assert(assert *objects.Assertions) *CaptureAssertions {
+	return &CaptureAssertions{
+		assert:  assert,
+		capture: c,
+	}
+}
+
+// Logged fails unless an entry at level with a message containing substring was captured
+func (ca *CaptureAssertions) Logged(level LogLevels, substring string) {
+	ca.assert.T().Helper()
+	if len(ca.capture.Find(level, substring)) == 0 {
+		ca.assert.Fail(fmt.Sprintf("Expected a %s containing %q to be logged, captured: %s", level, substring, ca.summary()))
+	}
+}
+
+// NotLogged fails if an entry at level with a message containing substring was captured
+func (ca *CaptureAssertions) NotLogged(level LogLevels, substring string) {
+	ca.assert.T().Helper()
+	if found := ca.capture.Find(level, substring); len(found) > 0 {
+		ca.assert.Fail(fmt.Sprintf("Expected no %s containing %q but found %q", level, substring, found[0].Message))
+	}
+}
+
+// LoggedField fails unless some captured entry has the field key set to value
+func (ca *CaptureAssertions) LoggedField(key string, value interface{}) {
+	ca.assert.T().Helper()
+	for _, e := range ca.capture.Entries() {
+		if v, ok := e.Fields[key]; ok && reflect.DeepEqual(v, value) {
+			return
+		}
+	}
+	ca.assert.Fail(fmt.Sprintf("Expected an entry with %s=%v, captured: %s", key, value, ca.summary()))
+}
+
+// NothingAbove fails if any entry more severe than level was captured
+func (ca *CaptureAssertions) NothingAbove(level LogLevels) {
+	ca.assert.T().Helper()
+	threshold, _ := severity(level)
+	for _, e := range ca.capture.Entries() {
+		if s, ok := severity(e.Level); ok && s > threshold {
+			ca.assert.Fail(fmt.Sprintf("Expected nothing above %s but found %s %q", level, e.Level, e.Message))
+			return
+		}
+	}
+}
+
+// Count fails unless exactly n entries were captured
+func (ca *CaptureAssertions) Count(n int) {
+	ca.assert.T().Helper()
+	if entries := ca.capture.Entries(); len(entries) != n {
+		ca.assert.Fail(fmt.Sprintf("Expected %d entries to be logged but found %d: %s", n, len(entries), ca.summary()))
+	}
+}
+
+func (ca *CaptureAssertions) summary() string {
+	entries := ca.capture.Entries()
+	if len(entries) == 0 {
+		return "(nothing)"
+	}
+
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = fmt.Sprintf("[%s] %s", e.Level, e.Message)
+	}
+	return strings.Join(lines, ", ")
+}
@@ -0,0 +1,54 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/servers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCaptureLogger(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	capture := logs.NewCaptureLogger()
+	previous := logs.GetRootLogger()
+	logs.SetRootLogger(capture)
+	defer logs.SetRootLogger(previous)
+
+	h := servers.NewHttpLogger("test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logs.GetLogger("handler").With("tenant", "acme").Warn("slow tenant %s", "acme")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/things", nil))
+
+	logged := capture.Assert(assert)
+	logged.Count(2)
+	logged.Logged(logs.INFO, "HTTP GET /things")
+	logged.Logged(logs.WARN, "slow tenant")
+	logged.NotLogged(logs.ERROR, "slow tenant")
+	logged.LoggedField("tenant", "acme")
+	logged.LoggedField("prefix", "test")
+	logged.NothingAbove(logs.WARN)
+
+	found := capture.Find(logs.WARN, "slow")
+	assert.Equal(1, len(found))
+	assert.Equal("handler", found[0].Name)
+
+	capture.Reset()
+	logged.Count(0)
+}
@@ -0,0 +1,81 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"fmt"
+	"github.com/threeguys/golang-toolkit/system"
+	"os"
+	"strings"
+)
+
+// LevelEnvironment is the variable read at startup for the level spec
+const LevelEnvironment = "LOG_LEVEL"
+
+func init() {
+	if err := ConfigureFromEnv(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid %s: %s\n", LevelEnvironment, err)
+	}
+}
+
+// ParseLevelSpec parses a comma separated level spec such as
+// INFO,servers=DEBUG,clients.tls=WARN where an entry without a name sets
+// the root level
+func ParseLevelSpec(spec string) (map[string]LogLevels, error) {
+	levels := make(map[string]LogLevels)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		name, value := RootName, item
+		if idx := strings.Index(item, "="); idx >= 0 {
+			name, value = strings.TrimSpace(item[:idx]), item[idx+1:]
+			if len(name) == 0 {
+				return nil, fmt.Errorf("missing logger name in %s", item)
+			}
+		}
+
+		level, err := ParseLevel(value)
+		if err != nil {
+			return nil, err
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
+
+// ApplyLevelSpec parses spec and sets every level in it, nothing is changed
+// if any part of the spec is invalid
+func ApplyLevelSpec(spec string) error {
+	levels, err := ParseLevelSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	for name, level := range levels {
+		if err := SetLevel(name, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigureFromEnv applies the level spec in the LOG_LEVEL environment variable
+func ConfigureFromEnv() error {
+	return ApplyLevelSpec(system.EnvOrDefault(LevelEnvironment, ""))
+}
@@ -0,0 +1,57 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"os"
+	"testing"
+)
+
+func TestParseLevelSpec(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+
+	levels, err := logs.ParseLevelSpec(" info, servers=DEBUG ,clients.tls=warn,")
+	assert.Nil(err)
+	assert.Equal(map[string]logs.LogLevels{
+		logs.RootName: logs.INFO,
+		"servers":     logs.DEBUG,
+		"clients.tls": logs.WARN,
+	}, levels)
+
+	_, err = logs.ParseLevelSpec("INFO,servers=LOUD")
+	assert.NotNil(err)
+
+	_, err = logs.ParseLevelSpec("=DEBUG")
+	assert.NotNil(err)
+}
+
+func TestConfigureFromEnv(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	defer logs.ClearLevel(logs.RootName)
+	defer logs.ClearLevel("servers")
+	defer os.Unsetenv(logs.LevelEnvironment)
+
+	assert.Nil(os.Setenv(logs.LevelEnvironment, "WARN,servers=DEBUG"))
+	assert.Nil(logs.ConfigureFromEnv())
+	assert.Equal(logs.LogLevels(logs.WARN), logs.GetLevel("clients"))
+	assert.Equal(logs.LogLevels(logs.DEBUG), logs.GetLevel("servers.tls"))
+
+	assert.Nil(os.Setenv(logs.LevelEnvironment, "ERROR,servers=nope"))
+	assert.NotNil(logs.ConfigureFromEnv())
+	assert.Equal(logs.LogLevels(logs.WARN), logs.GetLevel("clients"))
+}
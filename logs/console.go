@@ -0,0 +1,133 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// NoColorEnvironment turns off color when set to anything, see no-color.org
+const NoColorEnvironment = "NO_COLOR"
+
+const (
+	colorReset  = "\x1b[0m"
+	colorDim    = "\x1b[2m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorCyan   = "\x1b[36m"
+)
+
+var levelColors = map[LogLevels]string{
+	DEBUG: colorCyan,
+	INFO:  colorGreen,
+	WARN:  colorYellow,
+	ERROR: colorRed,
+}
+
+// ConsoleEncoder writes the LEVEL: (name) message format SimpleLogger has
+// always printed, coloring the level and field names when Color is set. The
+// timestamp is only included when Layout is set.
+type ConsoleEncoder struct {
+	TimeFormat
+	Color bool
+}
+
+func (ce ConsoleEncoder) Encode(e *Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	paint := func(color string, text string) {
+		if ce.Color && len(color) > 0 {
+			buf.WriteString(color)
+			buf.WriteString(text)
+			buf.WriteString(colorReset)
+		} else {
+			buf.WriteString(text)
+		}
+	}
+
+	if len(ce.Layout) > 0 && !e.Time.IsZero() {
+		paint(colorDim, ce.format(e.Time, ce.Layout))
+		buf.WriteString(" ")
+	}
+
+	paint(levelColors[e.Level], string(e.Level))
+	buf.WriteString(": ")
+	if len(e.Name) > 0 {
+		paint(colorDim, "("+e.Name+")")
+		buf.WriteString(" ")
+	}
+	buf.WriteString(e.Message)
+
+	for _, k := range e.Fields.Keys() {
+		buf.WriteString(" ")
+		paint(colorBlue, k+"=")
+		buf.WriteString(quoteIfNeeded(fmt.Sprintf("%v", e.Fields[k])))
+	}
+	if e.Caller != nil {
+		buf.WriteString(" ")
+		paint(colorDim, "("+e.Caller.String()+")")
+	}
+	buf.WriteString("\n")
+	if len(e.Stack) > 0 {
+		paint(colorDim, e.Stack)
+	}
+	return buf.Bytes(), nil
+}
+
+// IsTerminal reports whether out is a character device like a terminal,
+// rather than a pipe or regular file. It only needs os.File.Stat so works
+// without cgo.
+func IsTerminal(out io.Writer) bool {
+	f, ok := out.(interface{ Stat() (os.FileInfo, error) })
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ColorEnabled reports whether console output to out should be colored. It
+// isn't when NO_COLOR is set, TERM is dumb or out isn't a terminal.
+func ColorEnabled(out io.Writer) bool {
+	if len(os.Getenv(NoColorEnvironment)) > 0 || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(out)
+}
+
+// NewConsoleLogger returns a logger writing the console format to out, in
+// color if ColorEnabled says so
+func NewConsoleLogger(out io.Writer) WriterLogger {
+	return NewWriterLogger(out, ConsoleEncoder{Color: ColorEnabled(out)})
+}
+
+var stdoutOnce sync.Once
+var stdoutConsole WriterLogger
+
+// stdoutLogger is where the root SimpleLogger writes, it's created on first
+// use so NO_COLOR can be set any time before the first message
+func stdoutLogger() WriterLogger {
+	stdoutOnce.Do(func() {
+		stdoutConsole = NewConsoleLogger(os.Stdout)
+	})
+	return stdoutConsole
+}
@@ -0,0 +1,75 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestConsoleEncoder(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	e := &logs.Entry{
+		Level:   logs.WARN,
+		Name:    "servers",
+		Message: "slow request",
+		Fields:  logs.Fields{"path": "/a b", "ms": 1200},
+	}
+
+	plain, err := logs.ConsoleEncoder{}.Encode(e)
+	assert.Nil(err)
+	assert.Equal("WARN: (servers) slow request ms=1200 path=\"/a b\"\n", string(plain))
+
+	colored, err := logs.ConsoleEncoder{Color: true}.Encode(e)
+	assert.Nil(err)
+	assert.Equal("\x1b[33mWARN\x1b[0m: \x1b[2m(servers)\x1b[0m slow request "+
+		"\x1b[34mms=\x1b[0m1200 \x1b[34mpath=\x1b[0m\"/a b\"\n", string(colored))
+}
+
+func TestColorEnabled(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	f, err := ioutil.TempFile("", "console")
+	assert.Nil(err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	assert.False(logs.IsTerminal(f))
+	assert.False(logs.ColorEnabled(f))
+	assert.False(logs.IsTerminal(ioutil.Discard))
+
+	r, w, err := os.Pipe()
+	assert.Nil(err)
+	defer r.Close()
+	defer w.Close()
+	assert.False(logs.ColorEnabled(w))
+
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		assert.True(logs.IsTerminal(tty))
+
+		previous, set := os.LookupEnv(logs.NoColorEnvironment)
+		os.Setenv(logs.NoColorEnvironment, "1")
+		assert.False(logs.ColorEnabled(tty))
+		if set {
+			os.Setenv(logs.NoColorEnvironment, previous)
+		} else {
+			os.Unsetenv(logs.NoColorEnvironment)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	KeyTime    = "time"
+	KeyLevel   = "level"
+	KeyLogger  = "logger"
+	KeyMessage = "msg"
+	KeyCaller  = "caller"
+	KeyFunc    = "func"
+	KeyStack   = "stack"
+)
+
+// Encoder turns an entry into the bytes written out for it, including the
+// trailing newline
+type Encoder interface {
+	Encode(e *Entry) ([]byte, error)
+}
+
+// TimeFormat controls how an encoder renders the entry timestamp
+type TimeFormat struct {
+	Layout string
+	UTC    bool
+}
+
+func (tf TimeFormat) format(t time.Time, defaultLayout string) string {
+	layout := tf.Layout
+	if len(layout) == 0 {
+		layout = defaultLayout
+	}
+	if tf.UTC {
+		t = t.UTC()
+	}
+	return t.Format(layout)
+}
+
+// NewEncoder returns the encoder registered under name: text, json, logfmt or
+// console, which is colored if stdout is a terminal
+func NewEncoder(name string) (Encoder, error) {
+	switch strings.ToLower(name) {
+	case "text", "":
+		return TextEncoder{}, nil
+	case "json":
+		return JsonEncoder{}, nil
+	case "logfmt":
+		return LogfmtEncoder{}, nil
+	case "console":
+		return ConsoleEncoder{Color: ColorEnabled(os.Stdout)}, nil
+	}
+	return nil, fmt.Errorf("unknown log encoder %s", name)
+}
+
+// TextEncoder writes the human readable [LEVEL] name - message format. The
+// timestamp is only included when Layout is set, and any stack trace follows
+// on the lines after the message.
+type TextEncoder struct {
+	TimeFormat
+}
+
+func (te TextEncoder) Encode(e *Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	if len(te.Layout) > 0 && !e.Time.IsZero() {
+		buf.WriteString(te.format(e.Time, te.Layout))
+		buf.WriteString(" ")
+	}
+
+	buf.WriteString("[")
+	buf.WriteString(string(e.Level))
+	buf.WriteString("] ")
+	if len(e.Name) > 0 {
+		buf.WriteString(e.Name)
+		buf.WriteString(" ")
+	}
+	buf.WriteString("- ")
+	buf.WriteString(e.Message)
+	buf.WriteString(formatFields(e.Fields))
+	if e.Caller != nil {
+		buf.WriteString(" (")
+		buf.WriteString(e.Caller.String())
+		buf.WriteString(")")
+	}
+	buf.WriteString("\n")
+	if len(e.Stack) > 0 {
+		buf.WriteString(e.Stack)
+	}
+	return buf.Bytes(), nil
+}
+
+// JsonEncoder writes one JSON object per line. Fields are written alongside
+// the standard keys, and a field that collides with one is written as
+// fields.<name> instead. The timestamp defaults to RFC3339 with nanoseconds.
+type JsonEncoder struct {
+	TimeFormat
+}
+
+func (je JsonEncoder) Encode(e *Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+
+	first := true
+	write := func(key string, value interface{}) {
+		if !first {
+			buf.WriteString(",")
+		}
+		first = false
+
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteString(":")
+		buf.Write(jsonValue(value))
+	}
+
+	if !e.Time.IsZero() {
+		write(KeyTime, je.format(e.Time, time.RFC3339Nano))
+	}
+	write(KeyLevel, string(e.Level))
+	if len(e.Name) > 0 {
+		write(KeyLogger, e.Name)
+	}
+	write(KeyMessage, e.Message)
+	if e.Caller != nil {
+		write(KeyCaller, e.Caller.String())
+		write(KeyFunc, e.Caller.Function)
+	}
+	if len(e.Stack) > 0 {
+		write(KeyStack, e.Stack)
+	}
+
+	for _, k := range e.Fields.Keys() {
+		write(fieldKey(k), e.Fields[k])
+	}
+
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+func jsonValue(value interface{}) []byte {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+	return data
+}
+
+// LogfmtEncoder writes key=value pairs, quoting values where needed. The
+// timestamp defaults to RFC3339 with nanoseconds.
+type LogfmtEncoder struct {
+	TimeFormat
+}
+
+func (le LogfmtEncoder) Encode(e *Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	write := func(key string, value string) {
+		if buf.Len() > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(key)
+		buf.WriteString("=")
+		buf.WriteString(quoteIfNeeded(value))
+	}
+
+	if !e.Time.IsZero() {
+		write(KeyTime, le.format(e.Time, time.RFC3339Nano))
+	}
+	write(KeyLevel, string(e.Level))
+	if len(e.Name) > 0 {
+		write(KeyLogger, e.Name)
+	}
+	write(KeyMessage, e.Message)
+	if e.Caller != nil {
+		write(KeyCaller, e.Caller.String())
+		write(KeyFunc, e.Caller.Function)
+	}
+	if len(e.Stack) > 0 {
+		write(KeyStack, e.Stack)
+	}
+
+	for _, k := range e.Fields.Keys() {
+		write(fieldKey(k), fmt.Sprintf("%v", e.Fields[k]))
+	}
+
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+func fieldKey(key string) string {
+	switch key {
+	case KeyTime, KeyLevel, KeyLogger, KeyMessage, KeyCaller, KeyFunc, KeyStack:
+		return "fields." + key
+	}
+	return key
+}
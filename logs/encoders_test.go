@@ -0,0 +1,89 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"testing"
+	"time"
+)
+
+func testEntry() *logs.Entry {
+	return &logs.Entry{
+		Time:    time.Date(2020, 5, 1, 12, 30, 0, 0, time.FixedZone("EST", -5*60*60)),
+		Level:   logs.WARN,
+		Name:    "servers.tls",
+		Message: "handshake failed",
+		Fields:  logs.Fields{"peer": "client one", "msg": "clash", "err": errors.New("bad cert")},
+		Caller:  &logs.Caller{File: "tls.go", Line: 42, Function: "servers.Serve"},
+	}
+}
+
+func TestTextEncoder(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+
+	data, err := logs.TextEncoder{}.Encode(testEntry())
+	assert.Nil(err)
+	assert.Equal("[WARN] servers.tls - handshake failed err=\"bad cert\" msg=clash peer=\"client one\" (tls.go:42)\n", string(data))
+
+	enc := logs.TextEncoder{TimeFormat: logs.TimeFormat{Layout: time.Kitchen, UTC: true}}
+	data, err = enc.Encode(&logs.Entry{Time: testEntry().Time, Level: logs.INFO, Message: "hi"})
+	assert.Nil(err)
+	assert.Equal("5:30PM [INFO] - hi\n", string(data))
+}
+
+func TestJsonEncoder(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+
+	data, err := logs.JsonEncoder{TimeFormat: logs.TimeFormat{UTC: true}}.Encode(testEntry())
+	assert.Nil(err)
+	assert.Equal("{\"time\":\"2020-05-01T17:30:00Z\",\"level\":\"WARN\",\"logger\":\"servers.tls\","+
+		"\"msg\":\"handshake failed\",\"caller\":\"tls.go:42\",\"func\":\"servers.Serve\",\"err\":\"bad cert\",\"fields.msg\":\"clash\","+
+		"\"peer\":\"client one\"}\n", string(data))
+
+	values, err := objects.JsonToMap(data)
+	assert.Nil(err)
+	assert.Equal("handshake failed", values["msg"])
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+
+	data, err := logs.LogfmtEncoder{}.Encode(testEntry())
+	assert.Nil(err)
+	assert.Equal("time=2020-05-01T12:30:00-05:00 level=WARN logger=servers.tls msg=\"handshake failed\" "+
+		"caller=tls.go:42 func=servers.Serve err=\"bad cert\" fields.msg=clash peer=\"client one\"\n", string(data))
+}
+
+func TestWriterLogger_Encoder(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+
+	enc, err := logs.NewEncoder("json")
+	assert.Nil(err)
+	_, err = logs.NewEncoder("xml")
+	assert.NotNil(err)
+
+	buf := &bytes.Buffer{}
+	logs.NewWriterLogger(buf, enc).With("id", 7).Error("failed %d times", 3)
+	values, err := objects.JsonToMap(buf.Bytes())
+	assert.Nil(err)
+	assert.Equal("ERROR", values["level"])
+	assert.Equal("failed 3 times", values["msg"])
+	assert.Equal(float64(7), values["id"])
+}
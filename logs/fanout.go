@@ -0,0 +1,172 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Field names added to entries handed to the fallback when a sink fails
+const (
+	FieldSink      = "sink"
+	FieldSinkError = "sink_error"
+)
+
+// Sink is one destination of a FanoutLogger, it only receives entries at or
+// above Level
+type Sink struct {
+	Name   string
+	Level  LogLevels
+	Logger Logger
+
+	failures uint64
+}
+
+func NewSink(name string, level LogLevels, logger Logger) *Sink {
+	return &Sink{
+		Name:   name,
+		Level:  level,
+		Logger: logger,
+	}
+}
+
+// NewWriterSink returns a sink that encodes entries with encoder and writes
+// them to out
+func NewWriterSink(name string, level LogLevels, out io.Writer, encoder Encoder) *Sink {
+	return NewSink(name, level, NewWriterLogger(out, encoder))
+}
+
+// Accepts reports whether entries at level are written to the sink
+func (s *Sink) Accepts(level LogLevels) bool {
+	return meetsLevel(level, s.Level)
+}
+
+// Failures returns how many entries the sink has failed to write
+func (s *Sink) Failures() uint64 {
+	return atomic.LoadUint64(&s.failures)
+}
+
+// FanoutLogger writes every entry to each of its sinks that accepts the
+// level. When a sink fails the entry goes to Fallback instead, tagged with
+// the sink name and error, so nothing is lost silently. A nil Fallback
+// means the package fallback logger, see SetFallbackLogger.
+type FanoutLogger struct {
+	sinks    []*Sink
+	Fallback Logger
+}
+
+func NewFanoutLogger(sinks ...*Sink) *FanoutLogger {
+	return &FanoutLogger{
+		sinks: sinks,
+	}
+}
+
+func (f *FanoutLogger) Sinks() []*Sink {
+	return f.sinks
+}
+
+// Failures returns the total number of failed writes across every sink
+func (f *FanoutLogger) Failures() uint64 {
+	var total uint64
+	for _, s := range f.sinks {
+		total += s.Failures()
+	}
+	return total
+}
+
+func (f *FanoutLogger) accepts(level LogLevels) bool {
+	for _, s := range f.sinks {
+		if s.Accepts(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FanoutLogger) Log(level LogLevels, msg string, args ...interface{}) {
+	if f.accepts(level) {
+		reportError(f.LogEntry(newEntry(0, level, nil, msg, args)))
+	}
+}
+
+func (f *FanoutLogger) LogEntry(e *Entry) error {
+	for _, s := range f.sinks {
+		if !s.Accepts(e.Level) {
+			continue
+		}
+
+		if err := deliver(s.Logger, e); err != nil {
+			atomic.AddUint64(&s.failures, 1)
+			f.fail(s, e, err)
+		}
+	}
+	return nil
+}
+
+func (f *FanoutLogger) fail(s *Sink, e *Entry, err error) {
+	fallback := f.Fallback
+	if fallback == nil {
+		fallback = GetFallbackLogger()
+	}
+
+	failed := *e
+	failed.Fields = mergeFields(e.Fields, Fields{FieldSink: s.Name, FieldSinkError: err.Error()})
+	reportError(deliver(fallback, &failed))
+}
+
+// Close closes every sink whose logger can be closed and returns the first
+// error
+func (f *FanoutLogger) Close() error {
+	var first error
+	for _, s := range f.sinks {
+		if closer, ok := s.Logger.(io.Closer); ok {
+			if err := closer.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+func (f *FanoutLogger) Info(msg string, args ...interface{}) {
+	f.Log(INFO, msg, args...)
+}
+
+func (f *FanoutLogger) Warn(msg string, args ...interface{}) {
+	f.Log(WARN, msg, args...)
+}
+
+func (f *FanoutLogger) Debug(msg string, args ...interface{}) {
+	f.Log(DEBUG, msg, args...)
+}
+
+func (f *FanoutLogger) Error(msg string, args ...interface{}) {
+	f.Log(ERROR, msg, args...)
+}
+
+// Enabled reports whether any sink accepts level
+func (f *FanoutLogger) Enabled(level LogLevels) bool {
+	return f.accepts(level)
+}
+
+func (f *FanoutLogger) With(keysAndValues ...interface{}) Logger {
+	return f.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (f *FanoutLogger) WithFields(fields Fields) Logger {
+	return withFields(f, fields)
+}
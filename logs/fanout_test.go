@@ -0,0 +1,111 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestFanoutLogger_Levels(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	console := &bytes.Buffer{}
+	file := &bytes.Buffer{}
+	syslog := logs.NewCaptureLogger()
+
+	fanout := logs.NewFanoutLogger(
+		logs.NewWriterSink("console", logs.INFO, console, logs.TextEncoder{}),
+		logs.NewWriterSink("file", logs.DEBUG, file, logs.LogfmtEncoder{}),
+		logs.NewSink("syslog", logs.ERROR, syslog),
+	)
+
+	fanout.Debug("starting %d workers", 4)
+	fanout.With("port", 8080).Info("listening")
+	fanout.Error("disk full")
+
+	assert.Equal("[INFO] - listening port=8080\n[ERROR] - disk full\n", console.String())
+	assert.Equal(3, strings.Count(file.String(), "\n"))
+	assert.True(strings.Contains(file.String(), "msg=\"starting 4 workers\""))
+	syslog.Assert(assert).Count(1)
+	syslog.Assert(assert).Logged(logs.ERROR, "disk full")
+	assert.Equal(uint64(0), fanout.Failures())
+}
+
+func TestFanoutLogger_Fallback(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	good := logs.NewCaptureLogger()
+	fallback := logs.NewCaptureLogger()
+
+	broken := logs.NewSink("broken", logs.DEBUG, logs.WriterLogger{Writer: func([]byte) error {
+		return errors.New("disk gone")
+	}})
+	fanout := logs.NewFanoutLogger(broken, logs.NewSink("good", logs.DEBUG, good))
+	fanout.Fallback = fallback
+
+	fanout.Info("first")
+	fanout.Warn("second")
+
+	good.Assert(assert).Count(2)
+	fallback.Assert(assert).Count(2)
+	fallback.Assert(assert).Logged(logs.WARN, "second")
+	fallback.Assert(assert).LoggedField(logs.FieldSink, "broken")
+	fallback.Assert(assert).LoggedField(logs.FieldSinkError, "disk gone")
+	assert.Equal(uint64(2), broken.Failures())
+	assert.Equal(uint64(2), fanout.Failures())
+}
+
+func TestFanoutLogger_WithFailingSink(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	fallback := logs.NewCaptureLogger()
+
+	// Nothing listens on the address once the listener is closed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(err)
+	address := listener.Addr().String()
+	assert.Nil(listener.Close())
+
+	syslog := logs.NewSyslogLogger("tcp", address, logs.FacilityDaemon, "app")
+	defer syslog.Close()
+
+	broken := logs.NewSink("syslog", logs.DEBUG, syslog.With("k", "v"))
+	fanout := logs.NewFanoutLogger(broken)
+	fanout.Fallback = fallback
+
+	fanout.Info("lost")
+	assert.Equal(uint64(1), broken.Failures())
+	fallback.Assert(assert).Logged(logs.INFO, "lost")
+	fallback.Assert(assert).LoggedField(logs.FieldSink, "syslog")
+}
+
+func TestWriterLogger_ReportsFailures(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	fallback := logs.NewCaptureLogger()
+	previous := logs.GetFallbackLogger()
+	logs.SetFallbackLogger(fallback)
+	defer logs.SetFallbackLogger(previous)
+
+	before := logs.WriteFailures()
+	w := logs.WriterLogger{Writer: func([]byte) error { return errors.New("pipe closed") }}
+	w.Info("lost")
+
+	assert.Equal(before+1, logs.WriteFailures())
+	fallback.Assert(assert).Logged(logs.ERROR, "pipe closed")
+}
@@ -0,0 +1,214 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MissingValue is recorded for a trailing key passed to With without a value
+const MissingValue = "(MISSING)"
+
+// Fields are the structured key/value pairs carried by a logger and attached
+// to every entry it writes. Loggers never modify a Fields once it's been
+// handed to them, they copy it instead.
+type Fields map[string]interface{}
+
+// FieldsOf builds Fields out of alternating keys and values, the same way
+// objects.Map does. Keys that aren't strings are formatted with %v.
+func FieldsOf(keysAndValues ...interface{}) Fields {
+	fields := make(Fields, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprintf("%v", keysAndValues[i])
+		}
+
+		if i+1 < len(keysAndValues) {
+			fields[key] = keysAndValues[i+1]
+		} else {
+			fields[key] = MissingValue
+		}
+	}
+	return fields
+}
+
+// Keys returns the field names in sorted order so output is stable
+func (f Fields) Keys() []string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Lazy is a field value or message argument that's only computed when an
+// entry is actually written, so it costs nothing at a disabled level. Plain
+// func() interface{} and func() string values are treated the same way.
+type Lazy func() interface{}
+
+func resolveValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Lazy:
+		return v()
+	case func() interface{}:
+		return v()
+	case func() string:
+		return v()
+	}
+	return value
+}
+
+func isLazy(value interface{}) bool {
+	switch value.(type) {
+	case Lazy, func() interface{}, func() string:
+		return true
+	}
+	return false
+}
+
+// resolveFields evaluates any lazy values, returning fields itself when
+// there are none
+func resolveFields(fields Fields) Fields {
+	for _, v := range fields {
+		if isLazy(v) {
+			resolved := make(Fields, len(fields))
+			for k, v := range fields {
+				resolved[k] = resolveValue(v)
+			}
+			return resolved
+		}
+	}
+	return fields
+}
+
+// resolveArgs evaluates any lazy message arguments, returning args itself
+// when there are none
+func resolveArgs(args []interface{}) []interface{} {
+	for _, arg := range args {
+		if isLazy(arg) {
+			resolved := make([]interface{}, len(args))
+			for i, arg := range args {
+				resolved[i] = resolveValue(arg)
+			}
+			return resolved
+		}
+	}
+	return args
+}
+
+// mergeFields returns the union of base and extra with extra winning on
+// conflicts. Neither argument is modified.
+func mergeFields(base, extra Fields) Fields {
+	if len(base) == 0 {
+		return extra
+	} else if len(extra) == 0 {
+		return base
+	}
+
+	merged := make(Fields, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// formatFields renders fields as " key=value key=value", quoting values that
+// would otherwise be ambiguous
+func formatFields(fields Fields) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, k := range fields.Keys() {
+		sb.WriteString(" ")
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(quoteIfNeeded(fmt.Sprintf("%v", fields[k])))
+	}
+	return sb.String()
+}
+
+func quoteIfNeeded(value string) string {
+	if len(value) == 0 || strings.ContainsAny(value, " =\"\t\r\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// fieldLogger carries fields on behalf of a logger that has no state of its
+// own to keep them in, it's what those loggers return from With
+type fieldLogger struct {
+	target Logger
+	fields Fields
+	skip   int
+}
+
+func withFields(target Logger, fields Fields) Logger {
+	if fl, ok := target.(*fieldLogger); ok {
+		return &fieldLogger{target: fl.target, fields: mergeFields(fl.fields, fields), skip: fl.skip}
+	}
+	return &fieldLogger{target: target, fields: fields}
+}
+
+func (fl *fieldLogger) Log(level LogLevels, msg string, args ...interface{}) {
+	if !fl.target.Enabled(level) {
+		return
+	}
+	reportError(fl.LogEntry(newEntry(fl.skip, level, fl.fields, msg, args)))
+}
+
+func (fl *fieldLogger) LogEntry(e *Entry) error {
+	withFields := *e
+	withFields.Fields = resolveFields(mergeFields(fl.fields, e.Fields))
+	return deliver(fl.target, &withFields)
+}
+
+func (fl *fieldLogger) Info(msg string, args ...interface{}) {
+	fl.Log(INFO, msg, args...)
+}
+
+func (fl *fieldLogger) Warn(msg string, args ...interface{}) {
+	fl.Log(WARN, msg, args...)
+}
+
+func (fl *fieldLogger) Debug(msg string, args ...interface{}) {
+	fl.Log(DEBUG, msg, args...)
+}
+
+func (fl *fieldLogger) Error(msg string, args ...interface{}) {
+	fl.Log(ERROR, msg, args...)
+}
+
+func (fl *fieldLogger) Enabled(level LogLevels) bool {
+	return fl.target.Enabled(level)
+}
+
+func (fl *fieldLogger) With(keysAndValues ...interface{}) Logger {
+	return fl.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (fl *fieldLogger) WithFields(fields Fields) Logger {
+	return withFields(fl, fields)
+}
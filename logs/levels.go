@@ -0,0 +1,188 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RootName is the logger name that holds the level every other logger falls
+// back to when nothing more specific has been configured
+const RootName = ""
+
+// DefaultLevel is the effective level of the root when it hasn't been set
+const DefaultLevel = DEBUG
+
+var levelSeverity = map[LogLevels]int{
+	DEBUG: 0,
+	INFO:  1,
+	WARN:  2,
+	ERROR: 3,
+	NONE:  4,
+}
+
+var levelLock sync.RWMutex
+var configuredLevels = make(map[string]LogLevels)
+var knownLoggers = make(map[string]bool)
+
+// LoggerLevel describes the level of a single logger, as reported by ListLevels
+type LoggerLevel struct {
+	Name       string    `json:"name"`
+	Level      LogLevels `json:"level"`
+	Configured bool      `json:"configured"`
+}
+
+// ParseLevel converts a level name, in any case, into one of the LogLevels
+func ParseLevel(value string) (LogLevels, error) {
+	level := LogLevels(strings.ToUpper(strings.TrimSpace(value)))
+	if _, ok := severity(level); !ok {
+		return "", fmt.Errorf("unknown log level %s", value)
+	}
+	return level, nil
+}
+
+func severity(level LogLevels) (int, bool) {
+	s, ok := levelSeverity[level]
+	return s, ok
+}
+
+// parentName returns the next name up the dotted hierarchy, so servers.tls
+// becomes servers and servers becomes the root
+func parentName(name string) string {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		return name[:idx]
+	}
+	return RootName
+}
+
+// SetLevel configures the threshold for the named logger and every logger
+// below it in the hierarchy that doesn't have a level of its own
+func SetLevel(name string, level LogLevels) error {
+	if _, ok := severity(level); !ok {
+		return fmt.Errorf("unknown log level %s", level)
+	}
+
+	levelLock.Lock()
+	defer levelLock.Unlock()
+	configuredLevels[name] = level
+	return nil
+}
+
+// ClearLevel removes the level configured for name so it inherits again
+func ClearLevel(name string) {
+	levelLock.Lock()
+	defer levelLock.Unlock()
+	delete(configuredLevels, name)
+}
+
+// GetLevel resolves the effective level for name by walking up the dotted
+// hierarchy until it finds a configured level
+func GetLevel(name string) LogLevels {
+	levelLock.RLock()
+	defer levelLock.RUnlock()
+	return effectiveLevel(name)
+}
+
+func effectiveLevel(name string) LogLevels {
+	for {
+		if level, ok := configuredLevels[name]; ok {
+			return level
+		}
+		if name == RootName {
+			return DefaultLevel
+		}
+		name = parentName(name)
+	}
+}
+
+// GetConfiguredLevel returns the level set directly on name, if there is one
+func GetConfiguredLevel(name string) (LogLevels, bool) {
+	levelLock.RLock()
+	defer levelLock.RUnlock()
+	level, ok := configuredLevels[name]
+	return level, ok
+}
+
+func registerLogger(name string) {
+	levelLock.RLock()
+	known := knownLoggers[name]
+	levelLock.RUnlock()
+
+	if !known {
+		levelLock.Lock()
+		knownLoggers[name] = true
+		levelLock.Unlock()
+	}
+}
+
+// ListLevels returns the root, every logger handed out by GetLogger and every
+// name with a configured level, sorted by name
+func ListLevels() []LoggerLevel {
+	levelLock.RLock()
+	defer levelLock.RUnlock()
+
+	names := map[string]bool{RootName: true}
+	for name := range knownLoggers {
+		names[name] = true
+	}
+	for name := range configuredLevels {
+		names[name] = true
+	}
+
+	levels := make([]LoggerLevel, 0, len(names))
+	for name := range names {
+		_, configured := configuredLevels[name]
+		levels = append(levels, LoggerLevel{
+			Name:       name,
+			Level:      effectiveLevel(name),
+			Configured: configured,
+		})
+	}
+
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Name < levels[j].Name })
+	return levels
+}
+
+// IsEnabled reports whether a message at level would be written by the named
+// logger. Messages logged at NONE, or while the threshold is NONE, never are.
+func IsEnabled(name string, level LogLevels) bool {
+	msgSeverity, ok := severity(level)
+	if !ok {
+		return true
+	} else if level == NONE {
+		return false
+	}
+
+	threshold, _ := severity(GetLevel(name))
+	return msgSeverity >= threshold
+}
+
+// meetsLevel reports whether a message at level passes a fixed threshold,
+// unknown thresholds let everything through just like unknown levels
+func meetsLevel(level LogLevels, threshold LogLevels) bool {
+	msgSeverity, ok := severity(level)
+	if !ok {
+		return true
+	} else if level == NONE {
+		return false
+	}
+
+	min, ok := severity(threshold)
+	return !ok || msgSeverity >= min
+}
@@ -17,7 +17,12 @@ package logs
 
 import (
     "fmt"
+    "io"
+    "os"
     "runtime/debug"
+    "sync"
+    "sync/atomic"
+    "time"
 )
 
 type LogLevels string
@@ -36,18 +41,162 @@ type Logger interface {
     Warn(msg string, args ... interface{})
     Debug(msg string, args ... interface{})
     Error(msg string, args ... interface{})
+    With(keysAndValues ... interface{}) Logger
+    WithFields(fields Fields) Logger
+    // Enabled reports whether a message at level would be written, so callers
+    // can skip building expensive arguments for one that won't
+    Enabled(level LogLevels) bool
+}
+
+// Entry is a single formatted message on its way from the logger that
+// produced it to the logger that writes it out
+type Entry struct {
+    Time    time.Time
+    Level   LogLevels
+    Name    string
+    Message string
+    Fields  Fields
+    Caller  *Caller
+    Stack   string
+
+    // format is the message before it was formatted, for grouping entries
+    format  string
+}
+
+// Caller is the place in the source an entry was logged from
+type Caller struct {
+    File     string
+    Line     int
+    Function string
+}
+
+
+// EntryLogger is implemented by loggers that accept whole entries, which lets
+// them render the name of the logger a message came from
+type EntryLogger interface {
+    LogEntry(e *Entry) error
+}
+
+func forward(target Logger, e *Entry) {
+    reportError(deliver(target, e))
+}
+
+// deliver hands an entry to target, returning the error if target is an
+// EntryLogger that failed to write it
+func deliver(target Logger, e *Entry) error {
+    if el, ok := target.(EntryLogger); ok {
+        return el.LogEntry(e)
+    }
+
+    if len(e.Fields) > 0 {
+        target = target.WithFields(e.Fields)
+    }
+    target.Log(e.Level, "(%s) %s", e.Name, e.Message)
+    return nil
+}
+
+var fallbackLock sync.RWMutex
+var fallbackLogger = Logger(NewWriterLogger(os.Stderr, TextEncoder{}))
+var writeFailures uint64
+
+// SetFallbackLogger replaces the logger that write failures are reported to,
+// which is a text WriterLogger on stderr by default
+func SetFallbackLogger(log Logger) {
+    fallbackLock.Lock()
+    defer fallbackLock.Unlock()
+    fallbackLogger = log
+}
+
+func GetFallbackLogger() Logger {
+    fallbackLock.RLock()
+    defer fallbackLock.RUnlock()
+    return fallbackLogger
+}
+
+// WriteFailures returns how many entries loggers have failed to write
+func WriteFailures() uint64 {
+    return atomic.LoadUint64(&writeFailures)
+}
+
+// reportError counts a failed write and reports it to the fallback logger.
+// If that fails too the error goes straight to stderr.
+func reportError(err error) {
+    if err == nil {
+        return
+    }
+
+    atomic.AddUint64(&writeFailures, 1)
+    e := &Entry{
+        Time:    time.Now(),
+        Level:   ERROR,
+        Name:    "logs",
+        Message: fmt.Sprintf("Unable to write to logger: %s", err),
+        Stack:   string(debug.Stack()),
+    }
+    if ferr := deliver(GetFallbackLogger(), e); ferr != nil {
+        _, _ = fmt.Fprintf(os.Stderr, "%s\n%s", e.Message, e.Stack)
+    }
 }
 
 type SimpleLogger struct {
     name string
     parent Logger
+    fields Fields
+    skip int
 }
 
 func (l *SimpleLogger) Log(level LogLevels, msg string, args ... interface{}) {
-    if l.parent != nil {
-        l.parent.Log(level, msg, args...)
-    } else {
-        fmt.Printf("%s: %s\n", level, fmt.Sprintf(msg, args...))
+    if !IsEnabled(l.name, level) {
+        return
+    }
+
+    e := newEntry(l.skip, level, l.fields, msg, args)
+    e.Name = l.name
+    reportError(l.LogEntry(e))
+}
+
+// LogEntry names entries that arrive without a name after this logger and
+// drops them if that name isn't enabled for their level, so wrappers like
+// AsyncLogger around a named logger still honor its level
+func (l *SimpleLogger) LogEntry(e *Entry) error {
+    if len(e.Name) == 0 {
+        named := *e
+        named.Name = l.name
+        e = &named
+    }
+
+    if !IsEnabled(e.Name, e.Level) {
+        return nil
+    }
+
+    if len(l.fields) > 0 {
+        withFields := *e
+        withFields.Fields = resolveFields(mergeFields(l.fields, e.Fields))
+        e = &withFields
+    }
+
+    if parent := l.parentLogger(); parent != nil {
+        forward(parent, e)
+        return nil
+    }
+
+    return stdoutLogger().LogEntry(e)
+}
+
+func (l *SimpleLogger) Enabled(level LogLevels) bool {
+    return IsEnabled(l.name, level)
+}
+
+func (l *SimpleLogger) With(keysAndValues ... interface{}) Logger {
+    return l.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (l *SimpleLogger) WithFields(fields Fields) Logger {
+    return &SimpleLogger{
+        name:   l.name,
+        parent: l.parent,
+        fields: mergeFields(l.fields, fields),
+        skip:   l.skip,
     }
 }
 
@@ -67,27 +216,99 @@ func (l *SimpleLogger) Error(msg string, args ... interface{}) {
     l.Log(ERROR, msg, args...)
 }
 
+// parentLogger returns the logger entries are forwarded to. Loggers without
+// an explicit parent follow whatever the root is at the time of the call, so
+// package level loggers created before SetRootLogger still end up there.
+func (l *SimpleLogger) parentLogger() Logger {
+    if l.parent != nil {
+        return l.parent
+    } else if root := GetRootLogger(); root != Logger(l) {
+        return root
+    }
+    return nil
+}
+
+var rootLock sync.RWMutex
 var rootLogger = Logger(&SimpleLogger{ name:   "(root)" })
+
 func GetLogger(name string) Logger {
+    registerLogger(name)
     return &SimpleLogger{
         name:   name,
-        parent: rootLogger,
     }
 }
 
+func GetRootLogger() Logger {
+    rootLock.RLock()
+    defer rootLock.RUnlock()
+    return rootLogger
+}
+
 func SetRootLogger(log Logger) {
+    rootLock.Lock()
+    defer rootLock.Unlock()
     rootLogger = log
 }
 
+// WriterLogger encodes entries with Encoder, or a TextEncoder if it's nil,
+// and hands the result to Writer
 type WriterLogger struct {
-    Writer func([]byte) error
+    Writer  func([]byte) error
+    Encoder Encoder
+    fields  Fields
+}
+
+func NewWriterLogger(out io.Writer, encoder Encoder) WriterLogger {
+    return WriterLogger{
+        Writer: func(data []byte) error {
+            _, err := out.Write(data)
+            return err
+        },
+        Encoder: encoder,
+    }
 }
 
 func (w WriterLogger) Log(level LogLevels, msg string, args ...interface{}) {
-    output := fmt.Sprintf(msg, args...)
-    err := w.Writer([]byte(fmt.Sprintf("[%s] - %s\n", level, output)))
+    if !w.Enabled(level) {
+        return
+    }
+    reportError(w.LogEntry(newEntry(0, level, w.fields, msg, args)))
+}
+
+func (w WriterLogger) LogEntry(e *Entry) error {
+    if len(w.fields) > 0 {
+        withFields := *e
+        withFields.Fields = resolveFields(mergeFields(w.fields, e.Fields))
+        e = &withFields
+    }
+
+    encoder := w.Encoder
+    if encoder == nil {
+        encoder = TextEncoder{}
+    }
+
+    data, err := encoder.Encode(e)
     if err != nil {
-        fmt.Errorf("Unable to write to logger: %s", string(debug.Stack()))
+        return err
+    }
+    return w.Writer(data)
+}
+
+// Enabled is true for every level but NONE, a WriterLogger writes whatever
+// it's given
+func (w WriterLogger) Enabled(level LogLevels) bool {
+    return meetsLevel(level, DEBUG)
+}
+
+func (w WriterLogger) With(keysAndValues ...interface{}) Logger {
+    return w.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (w WriterLogger) WithFields(fields Fields) Logger {
+    return WriterLogger{
+        Writer:  w.Writer,
+        Encoder: w.Encoder,
+        fields:  mergeFields(w.fields, fields),
     }
 }
 
@@ -0,0 +1,179 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"bytes"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"testing"
+	"time"
+)
+
+func bufferRoot() (*bytes.Buffer, func()) {
+	buf := &bytes.Buffer{}
+	previous := logs.GetRootLogger()
+	logs.SetRootLogger(logs.WriterLogger{Writer: func(data []byte) error {
+		_, err := buf.Write(data)
+		return err
+	}})
+	return buf, func() { logs.SetRootLogger(previous) }
+}
+
+func TestGetLevel_Hierarchy(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	defer logs.ClearLevel(logs.RootName)
+	defer logs.ClearLevel("servers")
+	defer logs.ClearLevel("servers.tls.client")
+
+	assert.Equal(logs.LogLevels(logs.DefaultLevel), logs.GetLevel("servers.tls"))
+
+	assert.Nil(logs.SetLevel(logs.RootName, logs.INFO))
+	assert.Nil(logs.SetLevel("servers", logs.DEBUG))
+	assert.Nil(logs.SetLevel("servers.tls.client", logs.ERROR))
+	assert.NotNil(logs.SetLevel("servers", "LOUD"))
+
+	assert.Equal(logs.LogLevels(logs.INFO), logs.GetLevel("clients.tls"))
+	assert.Equal(logs.LogLevels(logs.DEBUG), logs.GetLevel("servers"))
+	assert.Equal(logs.LogLevels(logs.DEBUG), logs.GetLevel("servers.tls"))
+	assert.Equal(logs.LogLevels(logs.ERROR), logs.GetLevel("servers.tls.client.pool"))
+
+	logs.ClearLevel("servers")
+	assert.Equal(logs.LogLevels(logs.INFO), logs.GetLevel("servers.tls"))
+}
+
+func TestSimpleLogger_Filtering(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	buf, restore := bufferRoot()
+	defer restore()
+	defer logs.ClearLevel(logs.RootName)
+	defer logs.ClearLevel("servers")
+
+	assert.Nil(logs.SetLevel(logs.RootName, logs.INFO))
+	assert.Nil(logs.SetLevel("servers", logs.DEBUG))
+
+	logs.GetLogger("clients").Debug("dropped %d", 1)
+	logs.GetLogger("clients").Info("kept %d", 2)
+	logs.GetLogger("servers.tls").Debug("kept %d", 3)
+	assert.Equal("[INFO] clients - kept 2\n[DEBUG] servers.tls - kept 3\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(logs.SetLevel(logs.RootName, logs.NONE))
+	logs.GetLogger("clients").Error("dropped")
+	logs.GetLogger("servers").Log(logs.NONE, "dropped")
+	assert.Equal("", buf.String())
+}
+
+func TestSimpleLogger_LogEntryFiltering(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	buf, restore := bufferRoot()
+	defer restore()
+	defer logs.ClearLevel("servers")
+	assert.Nil(logs.SetLevel("servers", logs.WARN))
+
+	async := logs.NewAsyncLogger(logs.GetLogger("servers"), 10, logs.BlockWhenFull)
+	async.Debug("dropped")
+	async.Warn("kept")
+	assert.Nil(async.Close())
+
+	redacting := logs.NewRedactingLogger(logs.GetLogger("servers"), nil)
+	redacting.Info("dropped")
+	redacting.Error("password=%s", "hunter2")
+	assert.Equal("[WARN] servers - kept\n[ERROR] servers - password=[REDACTED]\n", buf.String())
+}
+
+func TestLogger_WithFields(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	buf, restore := bufferRoot()
+	defer restore()
+
+	log := logs.GetLogger("servers").With("request", "r-1", "tenant", "acme")
+	log.WithFields(logs.Fields{"peer": "client one", "tenant": "other"}).Info("hello %s", "there")
+	log.Warn("odd")
+	assert.Equal("[INFO] servers - hello there peer=\"client one\" request=r-1 tenant=other\n"+
+		"[WARN] servers - odd request=r-1 tenant=acme\n", buf.String())
+
+	buf.Reset()
+	w := logs.WriterLogger{Writer: func(data []byte) error {
+		_, err := buf.Write(data)
+		return err
+	}}
+	w.With("a", 1).With("dangling").Info("direct")
+	w.Info("plain")
+	assert.Equal("[INFO] - direct a=1 dangling=(MISSING)\n[INFO] - plain\n", buf.String())
+}
+
+func TestLogger_Enabled(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	defer logs.ClearLevel("servers")
+	assert.Nil(logs.SetLevel("servers", logs.WARN))
+
+	log := logs.GetLogger("servers.tls")
+	assert.False(log.Enabled(logs.INFO))
+	assert.True(log.Enabled(logs.ERROR))
+	assert.False(log.With("a", 1).Enabled(logs.DEBUG))
+
+	async := logs.NewAsyncLogger(log, 1, logs.DropNewest)
+	defer async.Close()
+	assert.False(async.Enabled(logs.INFO))
+
+	w := logs.WriterLogger{}
+	assert.True(w.Enabled(logs.DEBUG))
+	assert.False(w.Enabled(logs.NONE))
+}
+
+func TestLogger_Lazy(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	buf, restore := bufferRoot()
+	defer restore()
+	defer logs.ClearLevel("servers")
+	assert.Nil(logs.SetLevel("servers", logs.INFO))
+
+	calls := 0
+	expensive := logs.Lazy(func() interface{} {
+		calls++
+		return "computed"
+	})
+
+	log := logs.GetLogger("servers").With("state", expensive)
+	log.Debug("dump %v", expensive)
+	assert.Equal(0, calls)
+	assert.Equal("", buf.String())
+
+	log.Info("dump %v", expensive)
+	assert.Equal(2, calls)
+	assert.Equal("[INFO] servers - dump computed state=computed\n", buf.String())
+
+	async := logs.NewAsyncLogger(log, 1, logs.DropNewest)
+	defer async.Close()
+
+	wrappers := []logs.Logger{
+		async,
+		logs.NewRedactingLogger(log, nil),
+		logs.NewSamplingLogger(log, time.Hour, 1, 0),
+		logs.NewDedupeLogger(log, time.Hour),
+	}
+	for _, wrapper := range wrappers {
+		wrapper.Debug("dump %v", expensive)
+	}
+	logs.NewCaptureLogger().Log(logs.NONE, "dump %v", expensive)
+	assert.Equal(2, calls)
+
+	buf.Reset()
+	capture := logs.NewCaptureLogger()
+	capture.With("size", func() string { return "large" }).Info("sized")
+	capture.Assert(assert).LoggedField("size", "large")
+}
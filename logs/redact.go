@@ -0,0 +1,327 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces anything a Redactor masks
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactedFields are the field names a new Redactor masks
+var DefaultRedactedFields = []string{"password", "passwd", "secret", "token", "authorization", "api_key", "apikey"}
+
+var bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+var pemPattern = regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]+-----.*?-----END [A-Z0-9 ]+-----`)
+var cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// urlPasswordPattern finds the password in a URL like postgres://user:pw@host
+var urlPasswordPattern = regexp.MustCompile(`(\b[A-Za-z][A-Za-z0-9+.-]*://[^\s/:@]*:)[^\s/@]+@`)
+
+// authSchemes are masked along with their credentials when they're the value
+// of a name=value pair, so Authorization: Basic dXNlcjpwYXNz loses both words
+const authSchemes = `(?:basic|bearer|digest|negotiate|ntlm)\s+\S+`
+
+// maxRedactDepth stops redactValue following pointer cycles forever, anything
+// nested deeper is masked entirely
+const maxRedactDepth = 16
+
+type redactPattern struct {
+	pattern *regexp.Regexp
+	matches func(string) bool
+}
+
+// Redactor masks secrets in messages and fields. A field is masked when its
+// name contains one of the configured names, ignoring case, and the same
+// names are masked in name=value or name: value pairs inside messages.
+// Patterns are masked wherever they appear in a message or string value, as
+// are passwords in URLs.
+// Maps and structs in field values are masked key by key the same way.
+type Redactor struct {
+	lock     sync.RWMutex
+	names    []string
+	keyValue *regexp.Regexp
+	patterns []redactPattern
+}
+
+// NewRedactor returns a Redactor for DefaultRedactedFields, bearer tokens,
+// PEM blocks and anything that passes the Luhn check for a card number
+func NewRedactor() *Redactor {
+	r := &Redactor{}
+	r.AddFields(DefaultRedactedFields...)
+	r.AddPattern(bearerPattern)
+	r.AddPattern(pemPattern)
+	r.patterns = append(r.patterns, redactPattern{pattern: cardPattern, matches: luhnValid})
+	return r
+}
+
+func (r *Redactor) AddFields(names ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, name := range names {
+		r.names = append(r.names, strings.ToLower(name))
+	}
+
+	quoted := make([]string, len(r.names))
+	for i, name := range r.names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	r.keyValue = regexp.MustCompile(`(?i)([\w.-]*(?:` + strings.Join(quoted, "|") + `)[\w.-]*)(\s*[=:]\s*)(` + authSchemes + `|"[^"]*"|'[^']*'|\S+)`)
+}
+
+func (r *Redactor) AddPattern(pattern *regexp.Regexp) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.patterns = append(r.patterns, redactPattern{pattern: pattern})
+}
+
+// IsRedactedField reports whether values of the named field are masked
+func (r *Redactor) IsRedactedField(name string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	name = strings.ToLower(name)
+	for _, n := range r.names {
+		if strings.Contains(name, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) RedactString(value string) string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, p := range r.patterns {
+		check := p.matches
+		value = p.pattern.ReplaceAllStringFunc(value, func(match string) string {
+			if check == nil || check(match) {
+				return RedactedValue
+			}
+			return match
+		})
+	}
+
+	value = urlPasswordPattern.ReplaceAllString(value, "${1}"+RedactedValue+"@")
+	if r.keyValue != nil {
+		value = r.keyValue.ReplaceAllString(value, "${1}${2}"+RedactedValue)
+	}
+	return value
+}
+
+// RedactFields returns a copy of fields with secrets masked, fields itself
+// is left alone
+func (r *Redactor) RedactFields(fields Fields) Fields {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	redacted := make(Fields, len(fields))
+	for k, v := range fields {
+		redacted[k] = r.redactValue(k, v, 0)
+	}
+	return redacted
+}
+
+func (r *Redactor) redactValue(name string, value interface{}, depth int) interface{} {
+	if r.IsRedactedField(name) {
+		return RedactedValue
+	} else if depth > maxRedactDepth {
+		return RedactedValue
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.RedactString(v)
+	case error:
+		return r.RedactString(v.Error())
+	}
+
+	// Maps with string keys, like headers, are redacted key by key
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		redacted := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			redacted[key] = r.redactValue(key, iter.Value().Interface(), depth+1)
+		}
+		return redacted
+	} else if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.String {
+		redacted := make([]string, rv.Len())
+		for i := range redacted {
+			redacted[i] = r.RedactString(rv.Index(i).String())
+		}
+		return redacted
+	}
+
+	// Values that format themselves, like URLs, are redacted the way they'll
+	// be written, keeping the value itself if there was nothing to mask.
+	// Other structs, like config dumps, are redacted field by field.
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return value
+	}
+	if rendered, ok := formatSelf(value); ok {
+		if redacted := r.RedactString(rendered); redacted != rendered {
+			return redacted
+		}
+		return value
+	}
+	if rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Struct {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		return r.redactStruct(rv, depth)
+	}
+	return value
+}
+
+// redactStruct returns the exported fields of rv keyed by their json names,
+// so JSON output keeps its shape, masking fields by either name
+func (r *Redactor) redactStruct(rv reflect.Value, depth int) map[string]interface{} {
+	redacted := make(map[string]interface{}, rv.NumField())
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+
+		key := field.Name
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if len(tag) > 0 {
+			key = tag
+		}
+
+		if r.IsRedactedField(field.Name) {
+			redacted[key] = RedactedValue
+		} else {
+			redacted[key] = r.redactValue(key, rv.Field(i).Interface(), depth+1)
+		}
+	}
+	return redacted
+}
+
+// formatSelf renders values that decide how they're written themselves
+func formatSelf(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case fmt.Stringer:
+		return v.String(), true
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		return string(text), err == nil
+	case json.Marshaler:
+		data, err := v.MarshalJSON()
+		return string(data), err == nil
+	}
+	return "", false
+}
+
+// RedactEntry returns a copy of e with its message and fields redacted
+func (r *Redactor) RedactEntry(e *Entry) *Entry {
+	redacted := *e
+	redacted.Message = r.RedactString(e.Message)
+	redacted.Fields = r.RedactFields(e.Fields)
+	return &redacted
+}
+
+func luhnValid(match string) bool {
+	sum, digits := 0, 0
+	for i := len(match) - 1; i >= 0; i-- {
+		c := match[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// RedactingLogger masks secrets in every entry before passing it on
+type RedactingLogger struct {
+	target   Logger
+	redactor *Redactor
+}
+
+func NewRedactingLogger(target Logger, redactor *Redactor) *RedactingLogger {
+	if redactor == nil {
+		redactor = NewRedactor()
+	}
+	return &RedactingLogger{
+		target:   target,
+		redactor: redactor,
+	}
+}
+
+func (rl *RedactingLogger) Log(level LogLevels, msg string, args ...interface{}) {
+	if !rl.target.Enabled(level) {
+		return
+	}
+	reportError(rl.LogEntry(newEntry(0, level, nil, msg, args)))
+}
+
+func (rl *RedactingLogger) LogEntry(e *Entry) error {
+	forward(rl.target, rl.redactor.RedactEntry(e))
+	return nil
+}
+
+func (rl *RedactingLogger) Info(msg string, args ...interface{}) {
+	rl.Log(INFO, msg, args...)
+}
+
+func (rl *RedactingLogger) Warn(msg string, args ...interface{}) {
+	rl.Log(WARN, msg, args...)
+}
+
+func (rl *RedactingLogger) Debug(msg string, args ...interface{}) {
+	rl.Log(DEBUG, msg, args...)
+}
+
+func (rl *RedactingLogger) Error(msg string, args ...interface{}) {
+	rl.Log(ERROR, msg, args...)
+}
+
+func (rl *RedactingLogger) Enabled(level LogLevels) bool {
+	return rl.target.Enabled(level)
+}
+
+func (rl *RedactingLogger) With(keysAndValues ...interface{}) Logger {
+	return rl.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (rl *RedactingLogger) WithFields(fields Fields) Logger {
+	return withFields(rl, fields)
+}
@@ -0,0 +1,148 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EntryFilter selects entries by minimum level, logger name (including the
+// loggers below it), message substring and age. Zero values match everything.
+type EntryFilter struct {
+	Level    LogLevels
+	Name     string
+	Contains string
+	Since    time.Time
+}
+
+func (f EntryFilter) Matches(e *Entry) bool {
+	if len(f.Level) > 0 {
+		threshold, known := severity(f.Level)
+		if s, ok := severity(e.Level); known && ok && s < threshold {
+			return false
+		}
+	}
+
+	if len(f.Name) > 0 && e.Name != f.Name && !strings.HasPrefix(e.Name, f.Name+".") {
+		return false
+	}
+
+	if len(f.Contains) > 0 && !strings.Contains(e.Message, f.Contains) {
+		return false
+	}
+
+	return f.Since.IsZero() || !e.Time.Before(f.Since)
+}
+
+// RingLogger keeps the most recent Size entries for each level in memory, so
+// a burst of DEBUG doesn't push out the last few errors
+type RingLogger struct {
+	size  int
+	lock  sync.RWMutex
+	rings map[LogLevels]*entryRing
+}
+
+type entryRing struct {
+	entries []Entry
+	next    int
+}
+
+func (r *entryRing) add(e Entry, size int) {
+	if len(r.entries) < size {
+		r.entries = append(r.entries, e)
+	} else {
+		r.entries[r.next] = e
+	}
+	r.next = (r.next + 1) % size
+}
+
+func NewRingLogger(size int) *RingLogger {
+	if size < 1 {
+		size = 1
+	}
+	return &RingLogger{
+		size:  size,
+		rings: make(map[LogLevels]*entryRing),
+	}
+}
+
+func (r *RingLogger) Log(level LogLevels, msg string, args ...interface{}) {
+	if !r.Enabled(level) {
+		return
+	}
+	reportError(r.LogEntry(newEntry(0, level, nil, msg, args)))
+}
+
+func (r *RingLogger) LogEntry(e *Entry) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	ring, ok := r.rings[e.Level]
+	if !ok {
+		ring = &entryRing{}
+		r.rings[e.Level] = ring
+	}
+	ring.add(*e, r.size)
+	return nil
+}
+
+// Entries returns the retained entries that match filter, oldest first
+func (r *RingLogger) Entries(filter EntryFilter) []Entry {
+	r.lock.RLock()
+	var entries []Entry
+	for _, ring := range r.rings {
+		for i := range ring.entries {
+			if filter.Matches(&ring.entries[i]) {
+				entries = append(entries, ring.entries[i])
+			}
+		}
+	}
+	r.lock.RUnlock()
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries
+}
+
+func (r *RingLogger) Info(msg string, args ...interface{}) {
+	r.Log(INFO, msg, args...)
+}
+
+func (r *RingLogger) Warn(msg string, args ...interface{}) {
+	r.Log(WARN, msg, args...)
+}
+
+func (r *RingLogger) Debug(msg string, args ...interface{}) {
+	r.Log(DEBUG, msg, args...)
+}
+
+func (r *RingLogger) Error(msg string, args ...interface{}) {
+	r.Log(ERROR, msg, args...)
+}
+
+func (r *RingLogger) Enabled(level LogLevels) bool {
+	return meetsLevel(level, DEBUG)
+}
+
+func (r *RingLogger) With(keysAndValues ...interface{}) Logger {
+	return r.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (r *RingLogger) WithFields(fields Fields) Logger {
+	return withFields(r, fields)
+}
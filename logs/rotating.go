@@ -0,0 +1,334 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"compress/gzip"
+	"github.com/threeguys/golang-toolkit/calls"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const backupTimeLayout = "20060102T150405.000"
+const compressedSuffix = ".gz"
+
+// RotatingFile is an io.Writer over a log file that moves the file aside
+// once it reaches MaxSize bytes or has been open for Interval, whichever
+// comes first. Backups are named after the file with the rotation time
+// inserted before the extension, app-20200501T123000.000.log, and only the
+// newest MaxBackups are kept. Zero disables each limit. With Compress set,
+// backups are gzipped in the background so writers aren't held up.
+type RotatingFile struct {
+	Path       string
+	MaxSize    int64
+	Interval   time.Duration
+	MaxBackups int
+	Compress   bool
+	Mode       os.FileMode
+
+	lock   sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	last   time.Time
+
+	compressing map[string]bool
+	pending     sync.WaitGroup
+}
+
+func NewRotatingFile(path string) *RotatingFile {
+	return &RotatingFile{
+		Path: path,
+		Mode: 0640,
+	}
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+
+	if rf.file == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if rf.shouldRotate(len(p)) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Rotate moves the current file aside and starts a new one immediately
+func (rf *RotatingFile) Rotate() error {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+	return rf.rotate()
+}
+
+// Reopen closes and reopens Path without rotating, which is what logrotate
+// expects after it has moved the file itself
+func (rf *RotatingFile) Reopen() error {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+
+	if err := rf.close(); err != nil {
+		return err
+	}
+	return rf.open()
+}
+
+// ReopenOnHangup reopens the file every time the process receives a SIGHUP
+func (rf *RotatingFile) ReopenOnHangup() {
+	calls.Install(syscall.SIGHUP, calls.CheckedWithLogger(GetLogger("logs.rotating"), rf.Reopen))
+}
+
+// Close closes the file and waits for any backups still being compressed
+func (rf *RotatingFile) Close() error {
+	rf.lock.Lock()
+	err := rf.close()
+	rf.lock.Unlock()
+
+	rf.pending.Wait()
+	return err
+}
+
+func (rf *RotatingFile) shouldRotate(amt int) bool {
+	if rf.MaxSize > 0 && rf.size > 0 && rf.size+int64(amt) > rf.MaxSize {
+		return true
+	}
+	return rf.Interval > 0 && time.Since(rf.opened) >= rf.Interval
+}
+
+func (rf *RotatingFile) open() error {
+	mode := rf.Mode
+	if mode == 0 {
+		mode = 0640
+	}
+
+	file, err := os.OpenFile(rf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, mode)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	rf.opened = time.Now()
+	return nil
+}
+
+func (rf *RotatingFile) close() error {
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.close(); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(rf.Path); err == nil {
+		backup := rf.backupName(time.Now())
+		if err := os.Rename(rf.Path, backup); err != nil {
+			return err
+		}
+
+		if rf.Compress {
+			rf.compress(backup)
+		}
+	}
+
+	if err := rf.open(); err != nil {
+		return err
+	}
+	return rf.prune()
+}
+
+// compress gzips backup in the background, prune leaves it alone until
+// that's finished and then runs again
+func (rf *RotatingFile) compress(backup string) {
+	if rf.compressing == nil {
+		rf.compressing = make(map[string]bool)
+	}
+	rf.compressing[backup] = true
+	rf.pending.Add(1)
+
+	go func() {
+		defer rf.pending.Done()
+		reportError(compressFile(backup))
+
+		rf.lock.Lock()
+		delete(rf.compressing, backup)
+		err := rf.prune()
+		rf.lock.Unlock()
+		reportError(err)
+	}()
+}
+
+func (rf *RotatingFile) backupParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(rf.Path)
+	base := filepath.Base(rf.Path)
+	ext = filepath.Ext(base)
+	prefix = strings.TrimSuffix(base, ext) + "-"
+	return
+}
+
+// backupName picks a name for a backup rotated at t, moving forward a
+// millisecond at a time if that name (or its compressed form) is taken or
+// wouldn't sort after the previous backup
+func (rf *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := rf.backupParts()
+	t = t.Truncate(time.Millisecond)
+	if !t.After(rf.last) {
+		t = rf.last.Add(time.Millisecond)
+	}
+
+	for {
+		name := filepath.Join(dir, prefix+t.Format(backupTimeLayout)+ext)
+		_, err := os.Stat(name)
+		_, gzErr := os.Stat(name + compressedSuffix)
+		if os.IsNotExist(err) && os.IsNotExist(gzErr) {
+			rf.last = t
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// Backups returns the paths of the existing backups, oldest first. A backup
+// that's still being compressed is listed under its uncompressed name.
+func (rf *RotatingFile) Backups() ([]string, error) {
+	dir, prefix, ext := rf.backupParts()
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]string)
+	for _, info := range infos {
+		name := strings.TrimSuffix(info.Name(), compressedSuffix)
+		if info.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if _, err := time.Parse(backupTimeLayout, stamp); err != nil {
+			continue
+		}
+
+		if _, seen := found[name]; !seen || name == info.Name() {
+			found[name] = info.Name()
+		}
+	}
+
+	backups := make([]string, 0, len(found))
+	for _, file := range found {
+		backups = append(backups, filepath.Join(dir, file))
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return strings.TrimSuffix(backups[i], compressedSuffix) < strings.TrimSuffix(backups[j], compressedSuffix)
+	})
+	return backups, nil
+}
+
+func (rf *RotatingFile) prune() error {
+	if rf.MaxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := rf.Backups()
+	if err != nil {
+		return err
+	}
+
+	excess := len(backups) - rf.MaxBackups
+	for _, backup := range backups {
+		if excess <= 0 {
+			break
+		}
+		if rf.compressing[backup] {
+			continue
+		}
+
+		if err := os.Remove(backup); err != nil {
+			return err
+		}
+		excess--
+	}
+	return nil
+}
+
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	err = gzipTo(in, path+compressedSuffix)
+	if closeErr := in.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(path + compressedSuffix)
+		return err
+	}
+	return os.Remove(path)
+}
+
+func gzipTo(in *os.File, path string) error {
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err == nil {
+		err = gz.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"compress/gzip"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile_Size(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "rotating")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	rf := logs.NewRotatingFile(filepath.Join(dir, "app.log"))
+	rf.MaxSize = 20
+	rf.MaxBackups = 2
+	defer rf.Close()
+
+	log := logs.NewWriterLogger(rf, nil)
+	for i := 0; i < 5; i++ {
+		log.Info("line %d", i)
+	}
+
+	backups, err := rf.Backups()
+	assert.Nil(err)
+	assert.Equal(2, len(backups))
+
+	data, err := ioutil.ReadFile(backups[1])
+	assert.Nil(err)
+	assert.Equal("[INFO] - line 3\n", string(data))
+
+	data, err = ioutil.ReadFile(rf.Path)
+	assert.Nil(err)
+	assert.Equal("[INFO] - line 4\n", string(data))
+}
+
+func TestRotatingFile_CompressAndReopen(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "rotating")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	rf := logs.NewRotatingFile(filepath.Join(dir, "app.log"))
+	rf.Compress = true
+	defer rf.Close()
+
+	_, err = rf.Write([]byte("before\n"))
+	assert.Nil(err)
+	assert.Nil(rf.Rotate())
+
+	// Compression happens in the background, Close waits for it
+	assert.Nil(rf.Close())
+
+	backups, err := rf.Backups()
+	assert.Nil(err)
+	assert.Equal(1, len(backups))
+	assert.True(strings.HasSuffix(backups[0], ".log.gz"))
+
+	in, err := os.Open(backups[0])
+	assert.Nil(err)
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	assert.Nil(err)
+	data, err := ioutil.ReadAll(gz)
+	assert.Nil(err)
+	assert.Equal("before\n", string(data))
+
+	// Simulate logrotate moving the file out from under us
+	assert.Nil(os.Rename(rf.Path, rf.Path+".moved"))
+	assert.Nil(rf.Reopen())
+	_, err = rf.Write([]byte("after\n"))
+	assert.Nil(err)
+
+	data, err = ioutil.ReadFile(rf.Path)
+	assert.Nil(err)
+	assert.Equal("after\n", string(data))
+}
+
+func TestRotatingFile_PruneWhileCompressing(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "rotating")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	rf := logs.NewRotatingFile(filepath.Join(dir, "app.log"))
+	rf.Compress = true
+	rf.MaxBackups = 1
+
+	for i := 0; i < 4; i++ {
+		_, err = rf.Write([]byte("line\n"))
+		assert.Nil(err)
+		assert.Nil(rf.Rotate())
+	}
+	assert.Nil(rf.Close())
+
+	backups, err := rf.Backups()
+	assert.Nil(err)
+	assert.Equal(1, len(backups))
+	assert.True(strings.HasSuffix(backups[0], ".log.gz"))
+
+	infos, err := ioutil.ReadDir(dir)
+	assert.Nil(err)
+	assert.Equal(2, len(infos))
+}
@@ -0,0 +1,254 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FieldRepeated is set on dedupe summaries to the number of entries collapsed
+const FieldRepeated = "repeated"
+
+// SamplingLogger passes the first First entries with the same level, logger
+// name and message in each Interval, then only every Thereafter'th one.
+// Messages are grouped by their format string, before it is formatted, so
+// "attempt %d" is sampled as one message and entries sampled out of Log cost
+// almost nothing. An Interval of zero or less turns sampling off.
+type SamplingLogger struct {
+	target     Logger
+	Interval   time.Duration
+	First      int
+	Thereafter int
+
+	lock    sync.Mutex
+	started time.Time
+	counts  map[string]int
+	dropped uint64
+}
+
+func NewSamplingLogger(target Logger, interval time.Duration, first, thereafter int) *SamplingLogger {
+	return &SamplingLogger{
+		target:     target,
+		Interval:   interval,
+		First:      first,
+		Thereafter: thereafter,
+		counts:     make(map[string]int),
+	}
+}
+
+// sample counts another entry with the given level, name and format and
+// reports whether it should be kept
+func (s *SamplingLogger) sample(level LogLevels, name, format string) bool {
+	if s.Interval <= 0 {
+		return true
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.started) >= s.Interval {
+		s.started = now
+		s.counts = make(map[string]int)
+	}
+
+	key := string(level) + "|" + name + "|" + format
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.First || (s.Thereafter > 0 && (n-s.First)%s.Thereafter == 0) {
+		return true
+	}
+
+	atomic.AddUint64(&s.dropped, 1)
+	return false
+}
+
+// Dropped returns how many entries have been sampled out
+func (s *SamplingLogger) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *SamplingLogger) Log(level LogLevels, msg string, args ...interface{}) {
+	if s.target.Enabled(level) && s.sample(level, "", msg) {
+		forward(s.target, newEntry(0, level, nil, msg, args))
+	}
+}
+
+func (s *SamplingLogger) LogEntry(e *Entry) error {
+	format := e.format
+	if len(format) == 0 {
+		format = e.Message
+	}
+
+	if s.sample(e.Level, e.Name, format) {
+		forward(s.target, e)
+	}
+	return nil
+}
+
+func (s *SamplingLogger) Info(msg string, args ...interface{}) {
+	s.Log(INFO, msg, args...)
+}
+
+func (s *SamplingLogger) Warn(msg string, args ...interface{}) {
+	s.Log(WARN, msg, args...)
+}
+
+func (s *SamplingLogger) Debug(msg string, args ...interface{}) {
+	s.Log(DEBUG, msg, args...)
+}
+
+func (s *SamplingLogger) Error(msg string, args ...interface{}) {
+	s.Log(ERROR, msg, args...)
+}
+
+func (s *SamplingLogger) Enabled(level LogLevels) bool {
+	return s.target.Enabled(level)
+}
+
+func (s *SamplingLogger) With(keysAndValues ...interface{}) Logger {
+	return s.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (s *SamplingLogger) WithFields(fields Fields) Logger {
+	return withFields(s, fields)
+}
+
+// DedupeLogger writes the first of a run of identical entries, those with
+// the same level, logger name and message, then holds back the rest until
+// Interval has passed since the first. If any were held back a single
+// summary saying how many is written in their place.
+type DedupeLogger struct {
+	target   Logger
+	Interval time.Duration
+
+	lock    sync.Mutex
+	pending map[string]*duplicates
+}
+
+type duplicates struct {
+	first *Entry
+	count int
+	timer *time.Timer
+}
+
+func NewDedupeLogger(target Logger, interval time.Duration) *DedupeLogger {
+	return &DedupeLogger{
+		target:   target,
+		Interval: interval,
+		pending:  make(map[string]*duplicates),
+	}
+}
+
+func (d *DedupeLogger) Log(level LogLevels, msg string, args ...interface{}) {
+	if !d.target.Enabled(level) {
+		return
+	}
+	reportError(d.LogEntry(newEntry(0, level, nil, msg, args)))
+}
+
+func (d *DedupeLogger) LogEntry(e *Entry) error {
+	key := string(e.Level) + "|" + e.Name + "|" + e.Message
+
+	d.lock.Lock()
+	if dup, found := d.pending[key]; found {
+		dup.count++
+		d.lock.Unlock()
+		return nil
+	}
+
+	d.pending[key] = &duplicates{
+		first: e,
+		timer: time.AfterFunc(d.Interval, func() { d.expire(key) }),
+	}
+	d.lock.Unlock()
+
+	forward(d.target, e)
+	return nil
+}
+
+func (d *DedupeLogger) expire(key string) {
+	d.lock.Lock()
+	dup, found := d.pending[key]
+	delete(d.pending, key)
+	d.lock.Unlock()
+
+	if found {
+		d.summarize(dup)
+	}
+}
+
+func (d *DedupeLogger) summarize(dup *duplicates) {
+	if dup.count == 0 {
+		return
+	}
+
+	summary := *dup.first
+	summary.Time = time.Now()
+	summary.Message = fmt.Sprintf("%s (repeated %d times)", dup.first.Message, dup.count)
+	summary.Fields = mergeFields(dup.first.Fields, Fields{FieldRepeated: dup.count})
+	summary.Stack = ""
+	forward(d.target, &summary)
+}
+
+// Flush writes the summaries for every run still being held back
+func (d *DedupeLogger) Flush() {
+	d.lock.Lock()
+	pending := d.pending
+	d.pending = make(map[string]*duplicates)
+	d.lock.Unlock()
+
+	for _, dup := range pending {
+		dup.timer.Stop()
+		d.summarize(dup)
+	}
+}
+
+func (d *DedupeLogger) Close() error {
+	d.Flush()
+	return nil
+}
+
+func (d *DedupeLogger) Info(msg string, args ...interface{}) {
+	d.Log(INFO, msg, args...)
+}
+
+func (d *DedupeLogger) Warn(msg string, args ...interface{}) {
+	d.Log(WARN, msg, args...)
+}
+
+func (d *DedupeLogger) Debug(msg string, args ...interface{}) {
+	d.Log(DEBUG, msg, args...)
+}
+
+func (d *DedupeLogger) Error(msg string, args ...interface{}) {
+	d.Log(ERROR, msg, args...)
+}
+
+func (d *DedupeLogger) Enabled(level LogLevels) bool {
+	return d.target.Enabled(level)
+}
+
+func (d *DedupeLogger) With(keysAndValues ...interface{}) Logger {
+	return d.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (d *DedupeLogger) WithFields(fields Fields) Logger {
+	return withFields(d, fields)
+}
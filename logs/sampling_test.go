@@ -0,0 +1,106 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"testing"
+	"time"
+)
+
+func TestSamplingLogger(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	capture := logs.NewCaptureLogger()
+	s := logs.NewSamplingLogger(capture, time.Hour, 2, 3)
+
+	for i := 1; i <= 10; i++ {
+		s.Error("downstream failed: attempt %d", i)
+	}
+	s.Warn("downstream failed: attempt %d", 11)
+	s.With("a", 1).Info("different")
+
+	var messages []string
+	for _, e := range capture.Entries() {
+		messages = append(messages, e.Message)
+	}
+	assert.Equal([]string{
+		"downstream failed: attempt 1",
+		"downstream failed: attempt 2",
+		"downstream failed: attempt 5",
+		"downstream failed: attempt 8",
+		"downstream failed: attempt 11",
+		"different",
+	}, messages)
+	assert.Equal(uint64(6), s.Dropped())
+}
+
+func TestSamplingLogger_Root(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	capture := logs.NewCaptureLogger()
+	s := logs.NewSamplingLogger(capture, time.Hour, 1, 0)
+
+	previous := logs.GetRootLogger()
+	logs.SetRootLogger(s)
+	defer logs.SetRootLogger(previous)
+
+	for i := 1; i <= 3; i++ {
+		logs.GetLogger("servers").Error("attempt %d", i)
+		logs.GetLogger("clients").Error("attempt %d", i)
+	}
+
+	logged := capture.Assert(assert)
+	logged.Count(2)
+	logged.Logged(logs.ERROR, "attempt 1")
+	assert.Equal(uint64(4), s.Dropped())
+
+	capture.Reset()
+	off := logs.NewSamplingLogger(capture, 0, 0, 0)
+	off.Info("kept")
+	off.Info("kept")
+	capture.Assert(assert).Count(2)
+	assert.Equal(uint64(0), off.Dropped())
+}
+
+func TestDedupeLogger(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	capture := logs.NewCaptureLogger()
+	d := logs.NewDedupeLogger(capture, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		d.Error("connection refused")
+	}
+	d.Warn("connection refused")
+	d.Warn("connection refused")
+	assert.Equal(2, len(capture.Entries()))
+
+	assert.Nil(d.Close())
+	logged := capture.Assert(assert)
+	logged.Count(4)
+	logged.Logged(logs.ERROR, "connection refused (repeated 4 times)")
+	logged.Logged(logs.WARN, "connection refused (repeated 1 times)")
+	logged.LoggedField(logs.FieldRepeated, 4)
+
+	capture.Reset()
+	d = logs.NewDedupeLogger(capture, 10*time.Millisecond)
+	d.Info("tick")
+	d.Info("tick")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(capture.Entries()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	logged.Logged(logs.INFO, "tick (repeated 1 times)")
+}
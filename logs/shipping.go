@@ -0,0 +1,442 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const ContentTypeNdjson = "application/x-ndjson"
+
+// ShippingLogger batches entries and POSTs them to a collector at URL as
+// newline delimited JSON, or whatever Encoder writes. A batch is sent once
+// it holds BatchSize entries or FlushInterval has passed. Failed posts are
+// retried MaxRetries times, waiting Backoff and then twice as long each
+// time, on network errors, 429 and 5xx responses. Batches that still can't
+// be sent are appended to SpillPath, if it's set, and sent again once the
+// collector accepts a batch.
+//
+// Settings must be changed before the first entry is logged, which starts
+// the background goroutine doing the sending.
+type ShippingLogger struct {
+	Client        *http.Client
+	URL           string
+	Encoder       Encoder
+	Header        http.Header
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxPending    int
+	Gzip          bool
+	MaxRetries    int
+	Backoff       time.Duration
+	SpillPath     string
+
+	startOnce sync.Once
+	full      chan struct{}
+	flushes   chan chan struct{}
+	stop      chan struct{}
+	stopped   chan struct{}
+
+	lock    sync.Mutex
+	pending [][]byte
+	closed  bool
+
+	shipped uint64
+	spilled uint64
+	dropped uint64
+}
+
+// NewShippingLogger returns a logger shipping JSON entries to url with
+// client, which may be anything from http.DefaultClient to one made by
+// clients.NewMutualTlsClient
+func NewShippingLogger(client *http.Client, url string) *ShippingLogger {
+	return &ShippingLogger{
+		Client:        client,
+		URL:           url,
+		Encoder:       JsonEncoder{},
+		BatchSize:     100,
+		FlushInterval: 5 * time.Second,
+		MaxPending:    10000,
+		MaxRetries:    3,
+		Backoff:       500 * time.Millisecond,
+	}
+}
+
+func (s *ShippingLogger) start() {
+	s.startOnce.Do(func() {
+		s.full = make(chan struct{}, 1)
+		s.flushes = make(chan chan struct{})
+		s.stop = make(chan struct{})
+		s.stopped = make(chan struct{})
+		go s.run()
+	})
+}
+
+func (s *ShippingLogger) run() {
+	defer close(s.stopped)
+
+	var tick <-chan time.Time
+	if s.FlushInterval > 0 {
+		ticker := time.NewTicker(s.FlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			s.ship()
+		case <-s.full:
+			s.ship()
+		case done := <-s.flushes:
+			s.ship()
+			close(done)
+		case <-s.stop:
+			s.ship()
+			return
+		}
+	}
+}
+
+func (s *ShippingLogger) Log(level LogLevels, msg string, args ...interface{}) {
+	if !s.Enabled(level) {
+		return
+	}
+	reportError(s.LogEntry(newEntry(0, level, nil, msg, args)))
+}
+
+func (s *ShippingLogger) LogEntry(e *Entry) error {
+	encoder := s.Encoder
+	if encoder == nil {
+		encoder = JsonEncoder{}
+	}
+
+	line, err := encoder.Encode(e)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		s.send([][]byte{line})
+		return nil
+	}
+
+	if s.MaxPending > 0 && len(s.pending) >= s.MaxPending {
+		s.pending = s.pending[1:]
+		atomic.AddUint64(&s.dropped, 1)
+	}
+	s.pending = append(s.pending, line)
+	full := len(s.pending) >= s.BatchSize
+	s.lock.Unlock()
+
+	s.start()
+	if full {
+		select {
+		case s.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *ShippingLogger) take() [][]byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	batch := s.pending
+	s.pending = nil
+	return batch
+}
+
+// ship sends everything pending in batches, then anything spilled earlier
+// if the collector is taking batches again
+func (s *ShippingLogger) ship() {
+	batch := s.take()
+	for len(batch) > 0 {
+		n := len(batch)
+		if s.BatchSize > 0 && n > s.BatchSize {
+			n = s.BatchSize
+		}
+
+		if !s.send(batch[:n]) {
+			s.spill(batch[n:])
+			return
+		}
+		batch = batch[n:]
+	}
+	s.replay()
+}
+
+// send posts a batch and reports whether the collector can still be sent
+// to. A batch the collector rejected outright is dropped, since sending it
+// again won't help, while one that failed for any other reason is spilled.
+func (s *ShippingLogger) send(batch [][]byte) bool {
+	retry, err := s.post(batch)
+	if err == nil {
+		atomic.AddUint64(&s.shipped, uint64(len(batch)))
+		return true
+	}
+
+	reportError(err)
+	if !retry {
+		atomic.AddUint64(&s.dropped, uint64(len(batch)))
+		return true
+	}
+	s.spill(batch)
+	return false
+}
+
+// post sends a batch to the collector, retrying with backoff, and reports
+// whether a failure is worth trying again later
+func (s *ShippingLogger) post(batch [][]byte) (bool, error) {
+	body, err := s.body(batch)
+	if err != nil {
+		return false, err
+	}
+
+	wait := s.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.postOnce(body)
+		if err == nil {
+			return false, nil
+		} else if !retry || attempt >= s.MaxRetries {
+			return retry, err
+		}
+
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+func (s *ShippingLogger) body(batch [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var out io.Writer = &buf
+
+	var zw *gzip.Writer
+	if s.Gzip {
+		zw = gzip.NewWriter(&buf)
+		out = zw
+	}
+
+	for _, line := range batch {
+		if _, err := out.Write(line); err != nil {
+			return nil, err
+		}
+	}
+
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// postOnce makes a single attempt at sending body, reporting whether a
+// failure is worth retrying
+func (s *ShippingLogger) postOnce(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", ContentTypeNdjson)
+	if s.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("log collector %s returned %s", s.URL, resp.Status)
+}
+
+// spill appends entries that couldn't be sent to SpillPath, or drops them
+// if there isn't one
+func (s *ShippingLogger) spill(batch [][]byte) {
+	if len(batch) == 0 {
+		return
+	} else if len(s.SpillPath) == 0 {
+		atomic.AddUint64(&s.dropped, uint64(len(batch)))
+		return
+	}
+
+	f, err := os.OpenFile(s.SpillPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		atomic.AddUint64(&s.dropped, uint64(len(batch)))
+		reportError(err)
+		return
+	}
+	defer func() { reportError(f.Close()) }()
+
+	for i, line := range batch {
+		if _, err := f.Write(line); err != nil {
+			atomic.AddUint64(&s.dropped, uint64(len(batch)-i))
+			reportError(err)
+			return
+		}
+	}
+	atomic.AddUint64(&s.spilled, uint64(len(batch)))
+}
+
+// replay sends whatever has been spilled, stopping at the first batch that
+// fails in a way worth retrying and keeping the rest for next time. Batches
+// the collector rejects outright are dropped.
+func (s *ShippingLogger) replay() {
+	if len(s.SpillPath) == 0 {
+		return
+	}
+
+	data, err := ioutil.ReadFile(s.SpillPath)
+	if err != nil || len(data) == 0 {
+		return
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	for len(lines) > 0 {
+		n := len(lines)
+		if s.BatchSize > 0 && n > s.BatchSize {
+			n = s.BatchSize
+		}
+
+		if retry, err := s.post(lines[:n]); err == nil {
+			atomic.AddUint64(&s.shipped, uint64(n))
+		} else if !retry {
+			reportError(err)
+			atomic.AddUint64(&s.dropped, uint64(n))
+		} else {
+			reportError(ioutil.WriteFile(s.SpillPath, bytes.Join(lines, nil), 0600))
+			return
+		}
+		lines = lines[n:]
+	}
+	reportError(os.Remove(s.SpillPath))
+}
+
+// Flush sends everything logged so far, including anything spilled, and
+// waits for it to finish
+func (s *ShippingLogger) Flush() {
+	s.lock.Lock()
+	closed := s.closed
+	s.lock.Unlock()
+
+	if closed {
+		return
+	}
+
+	// Close may have stopped the goroutine since we checked, in which case
+	// it has already sent everything
+	s.start()
+	done := make(chan struct{})
+	select {
+	case s.flushes <- done:
+		<-done
+	case <-s.stopped:
+	}
+}
+
+// Close sends everything pending and stops the background goroutine.
+// Entries logged afterwards are sent one at a time as they're logged.
+func (s *ShippingLogger) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.lock.Unlock()
+
+	s.start()
+	close(s.stop)
+	<-s.stopped
+	return nil
+}
+
+// Shipped returns how many entries the collector has accepted
+func (s *ShippingLogger) Shipped() uint64 {
+	return atomic.LoadUint64(&s.shipped)
+}
+
+// Spilled returns how many entries have been written to SpillPath
+func (s *ShippingLogger) Spilled() uint64 {
+	return atomic.LoadUint64(&s.spilled)
+}
+
+// Dropped returns how many entries have been lost, because too many were
+// pending, the collector rejected them or they couldn't be sent or spilled
+func (s *ShippingLogger) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *ShippingLogger) Info(msg string, args ...interface{}) {
+	s.Log(INFO, msg, args...)
+}
+
+func (s *ShippingLogger) Warn(msg string, args ...interface{}) {
+	s.Log(WARN, msg, args...)
+}
+
+func (s *ShippingLogger) Debug(msg string, args ...interface{}) {
+	s.Log(DEBUG, msg, args...)
+}
+
+func (s *ShippingLogger) Error(msg string, args ...interface{}) {
+	s.Log(ERROR, msg, args...)
+}
+
+func (s *ShippingLogger) Enabled(level LogLevels) bool {
+	return meetsLevel(level, DEBUG)
+}
+
+func (s *ShippingLogger) With(keysAndValues ...interface{}) Logger {
+	return s.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (s *ShippingLogger) WithFields(fields Fields) Logger {
+	return withFields(s, fields)
+}
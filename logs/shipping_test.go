@@ -0,0 +1,276 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector is a stand in log collector that records every entry posted to
+// it, fails while down is set and rejects batches containing reject
+type collector struct {
+	lock     sync.Mutex
+	messages []string
+	batches  int
+	down     bool
+	gzipped  bool
+	reject   string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = zr
+		c.gzipped = true
+	}
+
+	var messages []string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry["msg"] == c.reject {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		messages = append(messages, entry["msg"].(string))
+	}
+
+	c.batches++
+	c.messages = append(c.messages, messages...)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (c *collector) setDown(down bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.down = down
+}
+
+func (c *collector) received() ([]string, int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string{}, c.messages...), c.batches
+}
+
+func quietFallback() func() {
+	previous := logs.GetFallbackLogger()
+	logs.SetFallbackLogger(logs.NewCaptureLogger())
+	return func() { logs.SetFallbackLogger(previous) }
+}
+
+func TestShippingLogger_Batches(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	s := logs.NewShippingLogger(server.Client(), server.URL)
+	s.BatchSize = 2
+	s.FlushInterval = time.Hour
+	s.Gzip = true
+
+	s.Info("one")
+	s.With("n", 2).Info("two")
+	s.Info("three")
+	assert.Nil(s.Close())
+
+	messages, batches := c.received()
+	assert.Equal([]string{"one", "two", "three"}, messages)
+	assert.Equal(2, batches)
+	assert.True(c.gzipped)
+	assert.Equal(uint64(3), s.Shipped())
+}
+
+func TestShippingLogger_Interval(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	s := logs.NewShippingLogger(server.Client(), server.URL)
+	s.FlushInterval = 10 * time.Millisecond
+	defer s.Close()
+
+	s.Warn("eventually")
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Shipped() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	messages, _ := c.received()
+	assert.Equal([]string{"eventually"}, messages)
+}
+
+func TestShippingLogger_Retry(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	failures := 2
+	var lock sync.Mutex
+	c := &collector{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		c.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	s := logs.NewShippingLogger(server.Client(), server.URL)
+	s.Backoff = time.Millisecond
+	s.Info("persistent")
+	s.Flush()
+	assert.Nil(s.Close())
+
+	messages, _ := c.received()
+	assert.Equal([]string{"persistent"}, messages)
+	assert.Equal(uint64(0), s.Spilled())
+}
+
+func TestShippingLogger_Spill(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	defer quietFallback()()
+
+	dir, err := ioutil.TempDir("", "shipping")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	c := &collector{down: true}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	s := logs.NewShippingLogger(server.Client(), server.URL)
+	s.BatchSize = 2
+	s.MaxRetries = 1
+	s.Backoff = time.Millisecond
+	s.SpillPath = filepath.Join(dir, "spill.ndjson")
+
+	s.Error("lost %d", 1)
+	s.Error("lost %d", 2)
+	s.Error("lost %d", 3)
+	s.Flush()
+
+	assert.Equal(uint64(3), s.Spilled())
+	info, err := os.Stat(s.SpillPath)
+	assert.Nil(err)
+	assert.Equal(os.FileMode(0600), info.Mode().Perm())
+
+	c.setDown(false)
+	s.Info("back")
+	assert.Nil(s.Close())
+
+	messages, _ := c.received()
+	sort.Strings(messages)
+	assert.Equal([]string{"back", "lost 1", "lost 2", "lost 3"}, messages)
+	assert.Equal(uint64(4), s.Shipped())
+	_, err = os.Stat(s.SpillPath)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestShippingLogger_FlushDuringClose(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	for i := 0; i < 50; i++ {
+		s := logs.NewShippingLogger(server.Client(), server.URL)
+		s.FlushInterval = time.Hour
+		s.Info("racing")
+
+		flushed := make(chan struct{})
+		go func() {
+			s.Flush()
+			close(flushed)
+		}()
+		assert.Nil(s.Close())
+
+		select {
+		case <-flushed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Flush blocked after Close")
+		}
+	}
+}
+
+func TestShippingLogger_Rejected(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	defer quietFallback()()
+
+	dir, err := ioutil.TempDir("", "shipping")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	c := &collector{reject: "bad"}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	s := logs.NewShippingLogger(server.Client(), server.URL)
+	s.BatchSize = 1
+	s.Backoff = time.Millisecond
+	s.SpillPath = filepath.Join(dir, "spill.ndjson")
+	defer s.Close()
+
+	// Rejected outright, so it's dropped rather than spilled
+	s.Info("bad")
+	s.Flush()
+	assert.Equal(uint64(1), s.Dropped())
+	assert.Equal(uint64(0), s.Spilled())
+
+	c.setDown(true)
+	s.Info("bad")
+	s.Info("outage")
+	s.Flush()
+	assert.Equal(uint64(2), s.Spilled())
+
+	// Replaying drops the rejected entry and carries on past it
+	c.setDown(false)
+	s.Flush()
+	messages, _ := c.received()
+	assert.Equal([]string{"outage"}, messages)
+	assert.Equal(uint64(2), s.Dropped())
+	_, err = os.Stat(s.SpillPath)
+	assert.True(os.IsNotExist(err))
+}
@@ -0,0 +1,65 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"log"
+	"strings"
+)
+
+// StandardWriter is an io.Writer that logs each write as a single message,
+// which is how the standard library log package calls it
+type StandardWriter struct {
+	Logger Logger
+	Level  LogLevels
+}
+
+func NewStandardWriter(logger Logger, level LogLevels) *StandardWriter {
+	return &StandardWriter{
+		Logger: logger,
+		Level:  level,
+	}
+}
+
+func (sw *StandardWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\r\n")
+	if len(msg) > 0 {
+		sw.Logger.Log(sw.Level, "%s", msg)
+	}
+	return len(p), nil
+}
+
+// RedirectStandardLog sends everything written through the standard library
+// log package to logger at level, dropping its own timestamp prefix since
+// the logger adds one. The returned function puts the previous output and
+// flags back.
+func RedirectStandardLog(logger Logger, level LogLevels) func() {
+	previousOutput, previousFlags := log.Writer(), log.Flags()
+
+	log.SetFlags(0)
+	log.SetOutput(NewStandardWriter(logger, level))
+
+	return func() {
+		log.SetOutput(previousOutput)
+		log.SetFlags(previousFlags)
+	}
+}
+
+// NewStandardLogger returns a *log.Logger, for libraries that insist on
+// one, which writes to logger at level
+func NewStandardLogger(logger Logger, level LogLevels) *log.Logger {
+	return log.New(NewStandardWriter(logger, level), "", 0)
+}
@@ -0,0 +1,50 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"errors"
+	"github.com/threeguys/golang-toolkit/calls"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"log"
+	"testing"
+)
+
+func TestRedirectStandardLog(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	buf, restore := bufferRoot()
+	defer restore()
+
+	undo := logs.RedirectStandardLog(logs.GetLogger("stdlog"), logs.WARN)
+	log.Println("from the standard library")
+	calls.LogErrors(func() error { return errors.New("failed op") })
+	undo()
+
+	assert.Equal("[WARN] stdlog - from the standard library\n[WARN] stdlog - failed op\n", buf.String())
+}
+
+func TestLogErrorsWithLogger(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	buf, restore := bufferRoot()
+	defer restore()
+	logs.SetErrorStacks(false)
+	defer logs.SetErrorStacks(true)
+
+	calls.LogErrorsWithLogger(logs.GetLogger("calls"), func() error { return errors.New("failed op") })
+	calls.CheckedWithLogger(logs.GetLogger("calls"), func() error { return nil })()
+	assert.Equal("[ERROR] calls - failed op\n", buf.String())
+}
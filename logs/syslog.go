@@ -0,0 +1,310 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Facility int
+
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLpr
+	FacilityNews
+	FacilityUucp
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFtp
+)
+
+const (
+	FacilityLocal0 Facility = iota + 16
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+type SyslogFormat int
+
+const (
+	RFC5424 SyslogFormat = iota
+	RFC3164
+)
+
+// SyslogStructuredDataId is the SD-ID fields are written under in RFC 5424
+// messages, 32473 being the enterprise number reserved for examples
+const SyslogStructuredDataId = "fields@32473"
+
+const syslogNil = "-"
+
+// DefaultSyslogTimeout bounds how long a new SyslogLogger waits to connect
+// or write before giving up on an entry
+const DefaultSyslogTimeout = 5 * time.Second
+
+var syslogSeverity = map[LogLevels]int{
+	ERROR: 3,
+	WARN:  4,
+	INFO:  6,
+	DEBUG: 7,
+}
+
+// SyslogLogger sends entries to a syslog daemon over udp, tcp or unixgram.
+// Messages over tcp use octet counted framing. A failed write drops the
+// connection and retries once on a fresh one. Connecting and each write are
+// limited to Timeout, so an unreachable daemon can't hold up every caller
+// for as long as the OS would wait, zero means no limit.
+type SyslogLogger struct {
+	Network  string
+	Address  string
+	Facility Facility
+	Format   SyslogFormat
+	AppName  string
+	Hostname string
+	Timeout  time.Duration
+
+	lock sync.Mutex
+	conn net.Conn
+}
+
+func NewSyslogLogger(network, address string, facility Facility, appName string) *SyslogLogger {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = syslogNil
+	}
+
+	return &SyslogLogger{
+		Network:  network,
+		Address:  address,
+		Facility: facility,
+		Format:   RFC5424,
+		AppName:  appName,
+		Hostname: hostname,
+		Timeout:  DefaultSyslogTimeout,
+	}
+}
+
+func (s *SyslogLogger) Log(level LogLevels, msg string, args ...interface{}) {
+	if !s.Enabled(level) {
+		return
+	}
+	reportError(s.LogEntry(newEntry(0, level, nil, msg, args)))
+}
+
+func (s *SyslogLogger) LogEntry(e *Entry) error {
+	msg := s.FormatEntry(e)
+	if strings.HasPrefix(s.Network, "tcp") {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if s.conn, err = net.DialTimeout(s.Network, s.Address, s.Timeout); err != nil {
+				continue
+			}
+		}
+
+		if s.Timeout > 0 {
+			if err = s.conn.SetWriteDeadline(time.Now().Add(s.Timeout)); err != nil {
+				_ = s.conn.Close()
+				s.conn = nil
+				continue
+			}
+		}
+
+		if _, err = s.conn.Write(msg); err == nil {
+			return nil
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// Priority returns the PRI value for an entry logged at level
+func (s *SyslogLogger) Priority(level LogLevels) int {
+	severity, ok := syslogSeverity[level]
+	if !ok {
+		severity = 5
+	}
+	return int(s.Facility)*8 + severity
+}
+
+// FormatEntry renders e in the configured RFC without any transport framing
+func (s *SyslogLogger) FormatEntry(e *Entry) []byte {
+	if s.Format == RFC3164 {
+		return s.format3164(e)
+	}
+	return s.format5424(e)
+}
+
+func (s *SyslogLogger) timestamp(e *Entry) time.Time {
+	if e.Time.IsZero() {
+		return time.Now()
+	}
+	return e.Time
+}
+
+func (s *SyslogLogger) format5424(e *Entry) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d %s ",
+		s.Priority(e.Level),
+		s.timestamp(e).Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeader(s.Hostname, 255),
+		syslogHeader(s.AppName, 48),
+		os.Getpid(),
+		syslogHeader(e.Name, 32))
+
+	if len(e.Fields) == 0 && e.Caller == nil {
+		buf.WriteString(syslogNil)
+	} else {
+		buf.WriteString("[")
+		buf.WriteString(SyslogStructuredDataId)
+		if e.Caller != nil {
+			writeSyslogParam(&buf, KeyCaller, e.Caller.String())
+		}
+		for _, k := range e.Fields.Keys() {
+			writeSyslogParam(&buf, k, fmt.Sprintf("%v", e.Fields[k]))
+		}
+		buf.WriteString("]")
+	}
+
+	buf.WriteString(" ")
+	buf.WriteString(e.Message)
+	writeSyslogStack(&buf, e.Stack)
+	return buf.Bytes()
+}
+
+func (s *SyslogLogger) format3164(e *Entry) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>%s %s %s[%d]: ",
+		s.Priority(e.Level),
+		s.timestamp(e).Format(time.Stamp),
+		syslogHeader(s.Hostname, 255),
+		syslogHeader(s.AppName, 32),
+		os.Getpid())
+
+	if len(e.Name) > 0 {
+		buf.WriteString(e.Name)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(e.Message)
+	buf.WriteString(formatFields(e.Fields))
+	writeSyslogStack(&buf, e.Stack)
+	return buf.Bytes()
+}
+
+// writeSyslogStack puts the stack on the lines after the message, where
+// daemons that accept multi-line messages keep it with the entry
+func writeSyslogStack(buf *bytes.Buffer, stack string) {
+	if stack = strings.TrimRight(stack, "\n"); len(stack) > 0 {
+		buf.WriteString("\n")
+		buf.WriteString(stack)
+	}
+}
+
+func (s *SyslogLogger) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// syslogHeader makes value safe for a header field, which must be printable
+// ASCII without spaces, or the nil value when it's empty
+func syslogHeader(value string, maxLen int) string {
+	return syslogName(value, maxLen, func(r rune) bool { return r > 32 && r < 127 })
+}
+
+func syslogName(value string, maxLen int, allowed func(rune) bool) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if allowed(r) {
+			return r
+		}
+		return '_'
+	}, value)
+
+	if len(cleaned) == 0 {
+		return syslogNil
+	} else if len(cleaned) > maxLen {
+		return cleaned[:maxLen]
+	}
+	return cleaned
+}
+
+var syslogParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func writeSyslogParam(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(" ")
+	buf.WriteString(syslogName(key, 32, func(r rune) bool {
+		return r > 32 && r < 127 && r != '=' && r != ']' && r != '"'
+	}))
+	buf.WriteString(`="`)
+	buf.WriteString(syslogParamEscaper.Replace(value))
+	buf.WriteString(`"`)
+}
+
+func (s *SyslogLogger) Info(msg string, args ...interface{}) {
+	s.Log(INFO, msg, args...)
+}
+
+func (s *SyslogLogger) Warn(msg string, args ...interface{}) {
+	s.Log(WARN, msg, args...)
+}
+
+func (s *SyslogLogger) Debug(msg string, args ...interface{}) {
+	s.Log(DEBUG, msg, args...)
+}
+
+func (s *SyslogLogger) Error(msg string, args ...interface{}) {
+	s.Log(ERROR, msg, args...)
+}
+
+func (s *SyslogLogger) Enabled(level LogLevels) bool {
+	return meetsLevel(level, DEBUG)
+}
+
+func (s *SyslogLogger) With(keysAndValues ...interface{}) Logger {
+	return s.WithFields(FieldsOf(keysAndValues...))
+}
+
+func (s *SyslogLogger) WithFields(fields Fields) Logger {
+	return withFields(s, fields)
+}
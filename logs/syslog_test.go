@@ -0,0 +1,148 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package logs_test
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogLogger_Format(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	s := logs.NewSyslogLogger("udp", "localhost:514", logs.FacilityLocal0, "my app")
+	s.Hostname = "host1"
+
+	e := &logs.Entry{
+		Time:    time.Date(2020, 5, 1, 12, 30, 0, 0, time.UTC),
+		Level:   logs.WARN,
+		Name:    "servers.tls",
+		Message: "handshake failed",
+		Fields:  logs.Fields{"peer": "cn=\"x]\"", "bad key": 1},
+	}
+	assert.Equal(fmt.Sprintf("<132>1 2020-05-01T12:30:00.000000Z host1 my_app %d servers.tls "+
+		"[fields@32473 bad_key=\"1\" peer=\"cn=\\\"x\\]\\\"\"] handshake failed", os.Getpid()),
+		string(s.FormatEntry(e)))
+
+	s.Format = logs.RFC3164
+	assert.Equal(fmt.Sprintf("<132>May  1 12:30:00 host1 my_app[%d]: servers.tls: handshake failed "+
+		"bad key=1 peer=\"cn=\\\"x]\\\"\"", os.Getpid()), string(s.FormatEntry(e)))
+
+	e.Stack = "main.main\n\tmain.go:10\n"
+	assert.True(strings.HasSuffix(string(s.FormatEntry(e)), "peer=\"cn=\\\"x]\\\"\"\nmain.main\n\tmain.go:10"))
+	s.Format = logs.RFC5424
+	assert.True(strings.HasSuffix(string(s.FormatEntry(e)), "] handshake failed\nmain.main\n\tmain.go:10"))
+
+	assert.Equal(8*16+7, s.Priority(logs.DEBUG))
+	assert.Equal(8*16+3, s.Priority(logs.ERROR))
+}
+
+func TestSyslogLogger_Udp(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(err)
+	defer conn.Close()
+
+	s := logs.NewSyslogLogger("udp", conn.LocalAddr().String(), logs.FacilityUser, "app")
+	defer s.Close()
+	s.Error("over %s", "udp")
+
+	buf := make([]byte, 1024)
+	assert.Nil(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(string(buf[:n]), "<11>1 "))
+	assert.True(strings.HasSuffix(string(buf[:n]), " - - over udp"))
+}
+
+func TestSyslogLogger_TcpReconnect(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(err)
+	defer listener.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			var length int
+			rdr := bufio.NewReader(conn)
+			if _, err := fmt.Fscanf(rdr, "%d ", &length); err == nil {
+				msg := make([]byte, length)
+				_, _ = rdr.Read(msg)
+				received <- string(msg)
+			}
+			conn.Close()
+		}
+	}()
+
+	s := logs.NewSyslogLogger("tcp", listener.Addr().String(), logs.FacilityDaemon, "app")
+	defer s.Close()
+
+	s.Info("first")
+	assert.True(strings.HasSuffix(<-received, " first"))
+
+	// The server hung up after the first message, keep writing until the
+	// logger notices and reconnects
+	deadline := time.Now().Add(5 * time.Second)
+	for len(received) == 0 && time.Now().Before(deadline) {
+		s.Info("second")
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(strings.HasSuffix(<-received, " second"))
+}
+
+func TestSyslogLogger_WriteTimeout(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(err)
+	defer listener.Close()
+
+	// Accept connections but never read from them, so writes back up
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+	defer func() {
+		for len(accepted) > 0 {
+			(<-accepted).Close()
+		}
+	}()
+
+	s := logs.NewSyslogLogger("tcp", listener.Addr().String(), logs.FacilityDaemon, "app")
+	s.Timeout = 50 * time.Millisecond
+	defer s.Close()
+
+	started := time.Now()
+	err = s.LogEntry(&logs.Entry{Level: logs.INFO, Message: strings.Repeat("x", 64<<20)})
+	assert.NotNil(err)
+	assert.True(time.Since(started) < 5*time.Second)
+}
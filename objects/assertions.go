@@ -30,6 +30,12 @@ func NewTestAssertions(t *testing.T) *Assertions {
     return &Assertions{ t: t }
 }
 
+// T returns the test the assertions report to, so helpers built on top of
+// Assertions can mark themselves with T().Helper()
+func (a *Assertions) T() *testing.T {
+    return a.t
+}
+
 func (a *Assertions) Fail(msg string) {
     a.t.Helper()
     if a.t == nil {
@@ -16,21 +16,30 @@
 package objects
 
 import (
+	"github.com/threeguys/golang-toolkit/calls"
 	"io"
-	"log"
 	"net/http"
 )
 
+// DefaultLogger is used by SafeClose and Successful, replace it with a
+// logs.Logger to have their output leveled and structured
+var DefaultLogger calls.ErrorLogger = calls.StandardLogger{}
+
 func SafeClose(closer io.Closer) {
-	err := closer.Close()
-	if err != nil {
-		log.Println(err)
-	}
+	SafeCloseWithLogger(DefaultLogger, closer)
+}
+
+func SafeCloseWithLogger(logger calls.ErrorLogger, closer io.Closer) {
+	calls.LogErrorsWithLogger(logger, closer.Close)
 }
 
 func Successful(err error, w http.ResponseWriter) bool {
+	return SuccessfulWithLogger(DefaultLogger, err, w)
+}
+
+func SuccessfulWithLogger(logger calls.ErrorLogger, err error, w http.ResponseWriter) bool {
 	if err != nil {
-		log.Println(err)
+		logger.Error("%s", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return false
 	}
@@ -47,3 +47,24 @@ func TestSuccessful(t *testing.T) {
 	assert.False(objects.Successful(errors.New("test error"), w))
 	assert.Equal(http.StatusInternalServerError, w.Result().StatusCode)
 }
+
+func TestSafeCloseWithLogger(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	ml := &mocks.MockErrorLogger{}
+
+	objects.SafeCloseWithLogger(ml, &mocks.MockCloser{ Err: nil })
+	assert.Equal(0, len(ml.Errors))
+
+	objects.SafeCloseWithLogger(ml, &mocks.MockCloser{Err: errors.New("this is a test")})
+	assert.Equal([]string{"this is a test"}, ml.Errors)
+}
+
+func TestSuccessfulWithLogger(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	ml := &mocks.MockErrorLogger{}
+
+	w := httptest.NewRecorder()
+	assert.False(objects.SuccessfulWithLogger(ml, errors.New("test error"), w))
+	assert.Equal(http.StatusInternalServerError, w.Result().StatusCode)
+	assert.Equal([]string{"test error"}, ml.Errors)
+}
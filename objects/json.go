@@ -74,6 +74,13 @@ func (gj *GenericJson) Get(name string) string {
 	return gj.GetOrDefault(name, "")
 }
 
+// Lookup returns the raw decoded value of a field, a string, float64, bool,
+// nil, []interface{} or map[string]interface{}, and whether it was present
+func (gj *GenericJson) Lookup(name string) (interface{}, bool) {
+	value, ok := gj.fields[name]
+	return value, ok
+}
+
 func (gj *GenericJson) AsObject(name string) *GenericJson {
 	if value, ok := gj.fields[name]; ok {
 		return &GenericJson{ fields: value.(map[string]interface{}), value: nil }
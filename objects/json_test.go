@@ -83,6 +83,23 @@ func TestGenericJson_Get(t *testing.T) {
 	assert.Equal("green", o2.Get("blue"))
 }
 
+func TestGenericJson_Lookup(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	obj, err := objects.NewGenericJson([]byte("{\"port\": 8080, \"tls\": {\"enabled\": true}}"))
+	assert.Nil(err)
+
+	port, found := obj.Lookup("port")
+	assert.True(found)
+	assert.Equal(8080.0, port)
+
+	tls, found := obj.Lookup("tls")
+	assert.True(found)
+	assert.Equal(map[string]interface{}{"enabled": true}, tls)
+
+	_, found = obj.Lookup("missing")
+	assert.False(found)
+}
+
 func TestGenericJson_AsList(t *testing.T) {
 	assert := objects.NewTestAssertions(t)
 	data := []byte("{\"alist\": [{\"a\":\"b\"},{\"c\":\"d\"}]}")
@@ -19,25 +19,27 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/threeguys/golang-toolkit/logs"
 	"github.com/threeguys/golang-toolkit/objects"
 	"io/ioutil"
-	"log"
 	"net/http"
 )
 
+var logger = logs.GetLogger("servers")
+
 type ResponseProducer func() (url string, resp *http.Response, err error)
 
 
 func ExecuteUrlCallback(req *http.Request, cb func(*http.Response)) {
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		log.Println("Error notifying of database failover", err)
+		logger.With("url", req.URL.String()).Error("Error notifying of database failover: %s", err)
 		return
 	}
 
-	defer objects.SafeClose(resp.Body)
+	defer objects.SafeCloseWithLogger(logger, resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		log.Println("Non-200 response from async url", req.URL, resp.StatusCode, resp.Status)
+		logger.With("url", req.URL.String(), "status", resp.StatusCode).Warn("Non-200 response from async url: %s", resp.Status)
 	}
 
 	if cb != nil {
@@ -46,7 +48,7 @@ func ExecuteUrlCallback(req *http.Request, cb func(*http.Response)) {
 	} else if resp.Body != nil {
 		_, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			log.Println("Error reading response body from the application")
+			logger.Error("Error reading response body from the application: %s", err)
 		}
 	}
 }
@@ -70,7 +72,7 @@ func WriteHttpError(w http.ResponseWriter, message string) error {
 func SafeWriteHttpError(w http.ResponseWriter, message string) {
 	err := WriteHttpError(w, message)
 	if err != nil {
-		log.Println(err)
+		logger.Error("%s", err)
 	}
 }
 
@@ -93,7 +95,7 @@ func SafeWriteHttpObject(w http.ResponseWriter, obj interface{}) {
 	data, err := json.Marshal(obj)
 	if err != nil {
 		SafeWriteHttpError(w, err.Error())
-		log.Println(err)
+		logger.Error("%s", err)
 		return
 	}
 
@@ -103,7 +105,7 @@ func SafeWriteHttpObject(w http.ResponseWriter, obj interface{}) {
 func SafeWriteHttpContent(w http.ResponseWriter, contentType string, content []byte) {
 	err := WriteHttpContent(w, contentType, content)
 	if err != nil {
-		log.Println(err)
+		logger.Error("%s", err)
 	}
 }
 
@@ -128,7 +130,7 @@ func GetProducerAsJson(getter ResponseProducer) (*objects.GenericJson, error) {
 		return nil, err
 	}
 
-	defer objects.SafeClose(resp.Body)
+	defer objects.SafeCloseWithLogger(logger, resp.Body)
 
 	if hdr := resp.Header.Get(HeaderContentType); len(hdr) > 0 {
 		if hdr != ContentTypeJson {
@@ -16,7 +16,7 @@
 package servers
 
 import (
-    "log"
+    "github.com/threeguys/golang-toolkit/logs"
     "net/http"
 )
 
@@ -26,29 +26,39 @@ const HeaderContentType = "Content-Type"
 const HeaderContentEncoding = "Content-Encoding"
 const HeaderContentLength = "Content-Length"
 const HeaderUserAgent = "User-Agent"
+const HeaderRequestId = "X-Request-Id"
 
 const ContentTypeHtml = "text/html"
 const ContentTypeJson = "application/json"
+const ContentTypeText = "text/plain"
 const ContentTypeFormEncoded = "application/x-www-form-urlencoded"
 
 
 type HttpLogger struct {
     prefix string
     delegate http.Handler
+    logger logs.Logger
 }
 
 func NewHttpLogger(prefix string, delegate http.Handler) *HttpLogger {
+    return NewHttpLoggerWithLogger(logs.GetLogger("servers.http"), prefix, delegate)
+}
+
+func NewHttpLoggerWithLogger(logger logs.Logger, prefix string, delegate http.Handler) *HttpLogger {
     return &HttpLogger {
         prefix: prefix,
         delegate: delegate,
+        logger: logger.With("prefix", prefix),
     }
 }
 
 func (h *HttpLogger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-    log.Printf("(%s) HTTP %s %s [%s]", h.prefix, r.Method, r.URL.Path, r.Host)
+    fields := logs.Fields{ "host": r.Host }
     if r.ContentLength > 0 {
-        log.Printf("    BODY[%s] %d", r.Header.Get("Content-Type"), r.ContentLength)
+        fields["content_type"] = r.Header.Get(HeaderContentType)
+        fields["content_length"] = r.ContentLength
     }
+    h.logger.WithFields(fields).Info("HTTP %s %s", r.Method, r.URL.Path)
     h.delegate.ServeHTTP(w, r)
 }
 
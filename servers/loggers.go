@@ -0,0 +1,92 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package servers
+
+import (
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"net/http"
+	"strings"
+)
+
+const levelPostLimit = 1024
+
+// LogLevelHandler lets an operator inspect and change logger levels on a
+// running process. The logger is picked with the name query parameter, and
+// leaving it off a PUT or DELETE targets the root.
+//
+//   GET                         lists every logger and its effective level
+//   GET    ?name=servers        returns a single logger
+//   PUT    ?name=servers&level=DEBUG  (or a {"level": "DEBUG"} body)
+//   DELETE ?name=servers        clears the level so servers inherits again
+type LogLevelHandler struct{}
+
+func NewLogLevelHandler() *LogLevelHandler {
+	return &LogLevelHandler{}
+}
+
+func (h *LogLevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := query.Get("name")
+
+	switch r.Method {
+	case http.MethodGet:
+		if _, found := query["name"]; found {
+			SafeWriteHttpObject(w, loggerLevel(name))
+		} else {
+			SafeWriteHttpObject(w, logs.ListLevels())
+		}
+
+	case http.MethodPut:
+		value := query.Get("level")
+		if len(value) == 0 {
+			body, err := objects.ParseJsonPost(r, levelPostLimit)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			value = body.Get("level")
+		}
+
+		level, err := logs.ParseLevel(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !objects.Successful(logs.SetLevel(name, level), w) {
+			return
+		}
+		SafeWriteHttpObject(w, loggerLevel(name))
+
+	case http.MethodDelete:
+		logs.ClearLevel(name)
+		SafeWriteHttpObject(w, loggerLevel(name))
+
+	default:
+		w.Header().Set("Allow", strings.Join([]string{http.MethodGet, http.MethodPut, http.MethodDelete}, ", "))
+		HttpRespond(http.StatusMethodNotAllowed, w)
+	}
+}
+
+func loggerLevel(name string) logs.LoggerLevel {
+	_, configured := logs.GetConfiguredLevel(name)
+	return logs.LoggerLevel{
+		Name:       name,
+		Level:      logs.GetLevel(name),
+		Configured: configured,
+	}
+}
@@ -0,0 +1,75 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package servers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/servers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	defer logs.ClearLevel("servers.admin")
+	logs.GetLogger("servers.admin")
+	h := servers.NewLogLevelHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/loggers", nil))
+	assert.Equal(http.StatusOK, w.Code)
+	var list []logs.LoggerLevel
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &list))
+	found := false
+	for _, l := range list {
+		found = found || l.Name == "servers.admin"
+	}
+	assert.True(found)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/loggers?name=servers.admin&level=warn", nil))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(logs.LogLevels(logs.WARN), logs.GetLevel("servers.admin.tls"))
+
+	w = httptest.NewRecorder()
+	body := bytes.NewBufferString("{\"level\": \"ERROR\"}")
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/loggers?name=servers.admin", body))
+	assert.Equal(http.StatusOK, w.Code)
+
+	var level logs.LoggerLevel
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/loggers?name=servers.admin", nil))
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &level))
+	assert.Equal(logs.LoggerLevel{Name: "servers.admin", Level: logs.ERROR, Configured: true}, level)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/loggers?name=servers.admin&level=LOUD", nil))
+	assert.Equal(http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/loggers?name=servers.admin", nil))
+	assert.Equal(http.StatusOK, w.Code)
+	_, configured := logs.GetConfiguredLevel("servers.admin")
+	assert.False(configured)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/loggers", nil))
+	assert.Equal(http.StatusMethodNotAllowed, w.Code)
+}
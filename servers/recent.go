@@ -0,0 +1,113 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package servers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/threeguys/golang-toolkit/logs"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RecentLogHandler serves the entries held by a logs.RingLogger. The query
+// parameters narrow them down:
+//
+//   level=WARN        only WARN and above
+//   logger=servers    only servers and the loggers below it
+//   q=timeout         only messages containing timeout
+//   since=5m          only the last five minutes, an RFC3339 time works too
+//   format=text       plain text instead of a JSON array
+type RecentLogHandler struct {
+	ring *logs.RingLogger
+}
+
+func NewRecentLogHandler(ring *logs.RingLogger) *RecentLogHandler {
+	return &RecentLogHandler{
+		ring: ring,
+	}
+}
+
+func (h *RecentLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		HttpRespond(http.StatusMethodNotAllowed, w)
+		return
+	}
+
+	filter, err := parseEntryFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entries := h.ring.Entries(filter)
+
+	if strings.EqualFold(r.URL.Query().Get("format"), "text") {
+		encoder := logs.TextEncoder{TimeFormat: logs.TimeFormat{Layout: time.RFC3339Nano}}
+		var buf bytes.Buffer
+		for i := range entries {
+			data, err := encoder.Encode(&entries[i])
+			if err != nil {
+				SafeWriteHttpError(w, err.Error())
+				return
+			}
+			buf.Write(data)
+		}
+		SafeWriteHttpContent(w, ContentTypeText, buf.Bytes())
+		return
+	}
+
+	items := make([]json.RawMessage, len(entries))
+	for i := range entries {
+		data, err := logs.JsonEncoder{}.Encode(&entries[i])
+		if err != nil {
+			SafeWriteHttpError(w, err.Error())
+			return
+		}
+		items[i] = bytes.TrimSpace(data)
+	}
+	SafeWriteHttpObject(w, items)
+}
+
+func parseEntryFilter(r *http.Request) (logs.EntryFilter, error) {
+	query := r.URL.Query()
+	filter := logs.EntryFilter{
+		Name:     query.Get("logger"),
+		Contains: query.Get("q"),
+	}
+
+	if value := query.Get("level"); len(value) > 0 {
+		level, err := logs.ParseLevel(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.Level = level
+	}
+
+	if value := query.Get("since"); len(value) > 0 {
+		if age, err := time.ParseDuration(value); err == nil {
+			filter.Since = time.Now().Add(-age)
+		} else if since, err := time.Parse(time.RFC3339, value); err == nil {
+			filter.Since = since
+		} else {
+			return filter, fmt.Errorf("invalid since %s, expected a duration or RFC3339 time", value)
+		}
+	}
+
+	return filter, nil
+}
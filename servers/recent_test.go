@@ -0,0 +1,68 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package servers_test
+
+import (
+	"encoding/json"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/servers"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecentLogHandler(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	ring := logs.NewRingLogger(3)
+	start := time.Now().Add(-time.Minute)
+	for i, name := range []string{"servers", "servers.tls", "clients"} {
+		for j, level := range []logs.LogLevels{logs.DEBUG, logs.DEBUG, logs.DEBUG, logs.ERROR} {
+			at := start.Add(time.Duration(i*4+j) * time.Second)
+			assert.Nil(ring.LogEntry(&logs.Entry{Time: at, Level: level, Name: name, Message: "from " + name}))
+		}
+	}
+	h := servers.NewRecentLogHandler(ring)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recent?"+query, nil))
+		return w
+	}
+
+	var entries []map[string]interface{}
+	w := get("")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &entries))
+	assert.Equal(6, len(entries))
+
+	entries = nil
+	assert.Nil(json.Unmarshal(get("level=error&logger=servers").Body.Bytes(), &entries))
+	assert.Equal(2, len(entries))
+	assert.Equal("servers", entries[0]["logger"])
+	assert.Equal("servers.tls", entries[1]["logger"])
+
+	w = get("format=text&q=clients&since=1h")
+	assert.Equal(servers.ContentTypeText, w.Header().Get(servers.HeaderContentType))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Equal(4, len(lines))
+	assert.True(strings.HasSuffix(lines[3], "[ERROR] clients - from clients"))
+
+	assert.Equal(http.StatusBadRequest, get("since=yesterday").Code)
+	assert.Equal(http.StatusBadRequest, get("level=LOUD").Code)
+}
@@ -0,0 +1,64 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package servers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/threeguys/golang-toolkit/logs"
+	"net/http"
+)
+
+// RequestLogger attaches a child logger carrying the method, path and request
+// id to every request's context. The id is taken from the X-Request-Id
+// header when the caller sent one, otherwise a new one is generated, and
+// it's echoed back in the response either way.
+type RequestLogger struct {
+	logger   logs.Logger
+	delegate http.Handler
+}
+
+func NewRequestLogger(logger logs.Logger, delegate http.Handler) *RequestLogger {
+	return &RequestLogger{
+		logger:   logger,
+		delegate: delegate,
+	}
+}
+
+func (rl *RequestLogger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(HeaderRequestId)
+	if len(id) == 0 {
+		id = NewRequestId()
+	}
+	w.Header().Set(HeaderRequestId, id)
+
+	logger := rl.logger.With("method", r.Method, "path", r.URL.Path, "request_id", id)
+	rl.delegate.ServeHTTP(w, r.WithContext(logs.NewContext(r.Context(), logger)))
+}
+
+// RequestLog returns the logger RequestLogger attached to r
+func RequestLog(r *http.Request) logs.Logger {
+	return logs.FromContext(r.Context())
+}
+
+// NewRequestId returns 16 random bytes, hex encoded
+func NewRequestId() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		logger.Error("Unable to generate request id: %s", err)
+	}
+	return hex.EncodeToString(id)
+}
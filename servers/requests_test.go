@@ -0,0 +1,56 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package servers_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/threeguys/golang-toolkit/logs"
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/servers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestLogger(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	buf := &bytes.Buffer{}
+	base := logs.NewWriterLogger(buf, nil)
+
+	h := servers.NewRequestLogger(base, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		servers.RequestLog(r).Info("handled")
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/things", nil)
+	r.Header.Set(servers.HeaderRequestId, "abc")
+	h.ServeHTTP(w, r)
+	assert.Equal("abc", w.Header().Get(servers.HeaderRequestId))
+	assert.Equal("[INFO] - handled method=GET path=/things request_id=abc\n", buf.String())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/other", nil))
+	assert.Equal(32, len(w.Header().Get(servers.HeaderRequestId)))
+}
+
+func TestFromContext_Fallback(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	assert.NotNil(logs.FromContext(context.Background()))
+
+	logger := logs.GetLogger("servers.test")
+	assert.Equal(logger, logs.FromContext(logs.NewContext(context.Background(), logger)))
+}
@@ -21,7 +21,6 @@ import (
 	"errors"
 	"github.com/threeguys/golang-toolkit/objects"
 	"io/ioutil"
-	"log"
 	"net/http"
 )
 
@@ -107,7 +106,7 @@ func NewServerMutualTlsConfig(cb *CertificateBundle) (*tls.Config, error) {
 		return nil, errors.New("key path must be set")
 	}
 
-	log.Println("Loading root cert:", cb.RootPath)
+	logger.With("path", cb.RootPath).Info("Loading root cert")
 	// Load the root CA cert
 	rootPem, err := ioutil.ReadFile(cb.RootPath)
 	if err != nil {
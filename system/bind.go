@@ -0,0 +1,514 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Struct tags understood by Bind
+const (
+	TagEnv       = "env"
+	TagDefault   = "default"
+	TagRequired  = "required"
+	TagPrefix    = "prefix"
+	TagSeparator = "sep"
+)
+
+// DefaultSeparator splits slice elements and map entries when a field has no
+// sep tag
+const DefaultSeparator = ","
+
+// ErrNotSet is the cause of a VarError for a required variable that's missing
+var ErrNotSet = errors.New("required but not set")
+
+var durationType = reflect.TypeOf(time.Duration(0))
+var urlType = reflect.TypeOf(url.URL{})
+var unmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// VarError is a problem with a single environment variable
+type VarError struct {
+	Name  string
+	Field string
+	Err   error
+}
+
+func (e *VarError) Error() string {
+	if len(e.Name) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Name, e.Err)
+}
+
+func (e *VarError) Unwrap() error {
+	return e.Err
+}
+
+// VarErrors is every problem Bind found, so they can all be fixed at once
+type VarErrors []*VarError
+
+func (errs VarErrors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d environment problems:", len(errs)))
+	for _, err := range errs {
+		sb.WriteString("\n\t")
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// Report lists every problem on its own line with the names lined up, along
+// with the field each one is for, for printing when a service won't start
+//
+//	2 configuration problems:
+//	  APP_PORT         0 must be at least 1 (Port)
+//	  APP_DB_PASSWORD  required but not set (Database.Password)
+func (errs VarErrors) Report() string {
+	var sb strings.Builder
+	if len(errs) == 1 {
+		sb.WriteString("1 configuration problem:\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("%d configuration problems:\n", len(errs)))
+	}
+
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	for _, err := range errs {
+		name := err.Name
+		if len(name) == 0 {
+			name = "-"
+		}
+		if len(err.Field) > 0 && err.Field != name {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s (%s)\n", name, err.Err, err.Field)
+		} else {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\n", name, err.Err)
+		}
+	}
+	_ = tw.Flush()
+	return sb.String()
+}
+
+// Is reports whether any of the errors is target, so errors.Is(err, ErrNotSet)
+// finds a missing variable among them
+func (errs VarErrors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bind fills in the struct cfg points to from the environment. Fields are
+// bound to the variable named by their env tag, falling back to their default
+// tag when it isn't set, and left alone if neither is. Nested structs without
+// an env tag are bound field by field, with their prefix tag prepended to
+// every name inside them. A nil pointer to a nested struct is only allocated
+// once one of its variables is set, so optional sections stay nil and their
+// required fields are only enforced when they're present.
+//
+//	type Config struct {
+//		Port     int           `env:"PORT" default:"8080"`
+//		Timeout  time.Duration `env:"TIMEOUT" default:"30s"`
+//		Peers    []string      `env:"PEERS" sep:";"`
+//		Database Database      `prefix:"DB_"`
+//	}
+//
+// Strings, bools, ints, uints, floats, durations, URLs, pointers, slices,
+// maps of key=value pairs and encoding.TextUnmarshaler implementations,
+// ByteSize among them, are supported. Constraints declared on fields, see
+// Validate, are checked once they're bound. Every missing required,
+// unparseable or invalid variable is returned together as VarErrors.
+func Bind(cfg interface{}) error {
+	return OS.BindPrefix("", cfg)
+}
+
+// BindPrefix is Bind with prefix prepended to every variable name
+func BindPrefix(prefix string, cfg interface{}) error {
+	return OS.BindPrefix(prefix, cfg)
+}
+
+// Bind fills in the struct cfg points to from the environment, see Bind
+func (e *Environment) Bind(cfg interface{}) error {
+	return e.BindPrefix("", cfg)
+}
+
+func (e *Environment) BindPrefix(prefix string, cfg interface{}) error {
+	v, err := configStruct(cfg)
+	if err != nil {
+		return err
+	}
+
+	found := settings(prefix, v)
+	set := make(map[string]bool, len(found))
+	missing := make(map[*VarError]*setting)
+	var errs VarErrors
+	for _, s := range found {
+		if len(s.env) == 0 {
+			continue
+		}
+
+		raw, present, err := e.lookup(s.env)
+		if err != nil {
+			errs = append(errs, &VarError{Name: s.env, Field: s.path, Err: err})
+			continue
+		} else if present {
+			s.attach()
+		} else {
+			raw, present = s.field.Tag.Lookup(TagDefault)
+		}
+
+		if !present {
+			if s.required() {
+				err := &VarError{Name: s.env, Field: s.path, Err: ErrNotSet}
+				missing[err] = s
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if err := s.parse(raw); err != nil {
+			errs = append(errs, &VarError{Name: s.env, Field: s.path, Err: err})
+		}
+		set[s.path] = true
+	}
+
+	// Required fields of optional sections that were never set don't count
+	present := errs[:0]
+	for _, err := range errs {
+		if s, found := missing[err]; !found || !s.detached() {
+			present = append(present, err)
+		}
+	}
+
+	errs = validateSettings(found, present, settingName, func(s *setting) bool {
+		return set[s.path]
+	})
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func configStruct(cfg interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("cannot bind to %T, it must be a pointer to a struct", cfg)
+	}
+	return v.Elem(), nil
+}
+
+// setting is a single field of a config struct along with the names it's
+// known by in each place its value can come from
+type setting struct {
+	field reflect.StructField
+	value reflect.Value
+	// path is the field path, Database.Port
+	path string
+	// env is the variable name with prefixes, empty if it has none
+	env string
+	// keys lead to the field through nested JSON objects, nil if it has none
+	keys []string
+	// sections are the nil pointers to structs the field is inside of,
+	// outermost first
+	sections []*section
+}
+
+// section is a nil pointer to a nested struct along with the struct it will
+// point to once one of its fields is set
+type section struct {
+	field reflect.Value
+	value reflect.Value
+}
+
+// attach points every section the setting is inside of at its struct
+func (s *setting) attach() {
+	for _, sec := range s.sections {
+		if sec.field.IsNil() {
+			sec.field.Set(sec.value)
+		}
+	}
+}
+
+// detached reports whether the setting is inside a section that's still nil,
+// so its value isn't part of the config
+func (s *setting) detached() bool {
+	for _, sec := range s.sections {
+		if sec.field.IsNil() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *setting) required() bool {
+	required, _ := strconv.ParseBool(s.field.Tag.Get(TagRequired))
+	return required
+}
+
+// parse sets the setting from raw, keeping raw out of the error if it's a
+// secret
+func (s *setting) parse(raw string) error {
+	err := parseValue(s.value, raw, separator(s.field))
+	if err != nil && s.secret() {
+		return &secretError{err: err, kind: s.value.Type()}
+	}
+	return err
+}
+
+// secretError hides a parse error, which usually quotes the value, behind
+// one that only says what the value should have been
+type secretError struct {
+	err  error
+	kind reflect.Type
+}
+
+func (e *secretError) Error() string {
+	return fmt.Sprintf("value is not a valid %s", e.kind)
+}
+
+func (e *secretError) Unwrap() error {
+	return e.err
+}
+
+// settings lists every field of the struct v, descending into nested structs
+// that don't have an env tag of their own
+func settings(prefix string, v reflect.Value) []*setting {
+	var found []*setting
+	walkStruct(prefix, "", []string{}, nil, v, &found)
+	return found
+}
+
+func walkStruct(prefix string, path string, keys []string, sections []*section, v reflect.Value, found *[]*setting) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 && !field.Anonymous {
+			continue
+		}
+
+		fieldPath := field.Name
+		if len(path) > 0 {
+			fieldPath = path + "." + field.Name
+		}
+
+		fieldKeys := jsonKeys(keys, field)
+		fv := v.Field(i)
+		name, tagged := field.Tag.Lookup(TagEnv)
+		if !tagged {
+			if nested, sec := nestedStruct(fv); nested.IsValid() {
+				if field.Anonymous {
+					fieldPath, fieldKeys = path, keys
+				}
+
+				nestedSections := sections
+				if sec != nil {
+					nestedSections = append(append([]*section{}, sections...), sec)
+				}
+				walkStruct(prefix+field.Tag.Get(TagPrefix), fieldPath, fieldKeys, nestedSections, nested, found)
+				continue
+			}
+		}
+
+		s := &setting{field: field, value: fv, path: fieldPath, keys: fieldKeys, sections: sections}
+		if tagged && name != "-" {
+			s.env = prefix + name
+		}
+		*found = append(*found, s)
+	}
+}
+
+// jsonKeys appends the field's JSON name, from its json tag like
+// encoding/json does, to the keys leading to its struct
+func jsonKeys(keys []string, field reflect.StructField) []string {
+	if keys == nil {
+		return nil
+	}
+
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return nil
+	} else if len(name) == 0 {
+		name = field.Name
+	}
+
+	fieldKeys := make([]string, len(keys)+1)
+	copy(fieldKeys, keys)
+	fieldKeys[len(keys)] = name
+	return fieldKeys
+}
+
+// nestedStruct returns the struct a field holds, or an invalid value if the
+// field isn't one to descend into. If the field is a nil pointer the struct
+// is a new one, along with the section that attaches it to the field.
+func nestedStruct(v reflect.Value) (reflect.Value, *section) {
+	if !v.CanSet() || isUnmarshaler(v) {
+		return reflect.Value{}, nil
+	}
+
+	switch {
+	case v.Kind() == reflect.Struct:
+		return v, nil
+	case v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct:
+		if v.IsNil() {
+			p := reflect.New(v.Type().Elem())
+			return p.Elem(), &section{field: v, value: p}
+		}
+		nested, _ := nestedStruct(v.Elem())
+		return nested, nil
+	}
+	return reflect.Value{}, nil
+}
+
+func isUnmarshaler(v reflect.Value) bool {
+	return v.Type().Implements(unmarshalerType) || reflect.PtrTo(v.Type()).Implements(unmarshalerType)
+}
+
+func separator(field reflect.StructField) string {
+	if sep, found := field.Tag.Lookup(TagSeparator); found && len(sep) > 0 {
+		return sep
+	}
+	return DefaultSeparator
+}
+
+// parseValue parses raw into v according to its type, sep splits slice
+// elements and map entries
+func parseValue(v reflect.Value, raw string, sep string) error {
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(raw))
+		}
+	}
+
+	switch v.Type() {
+	case durationType:
+		d, err := parseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+
+	case urlType:
+		u, err := parseURL(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*u))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(raw), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := parseValue(p.Elem(), raw, sep); err != nil {
+			return err
+		}
+		v.Set(p)
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(raw))
+			return nil
+		}
+
+		items := splitList(raw, sep)
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := parseValue(slice.Index(i), item, sep); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		v.Set(slice)
+
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for _, item := range splitList(raw, sep) {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("%q is not a key=value pair", item)
+			}
+
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := parseValue(key, strings.TrimSpace(kv[0]), sep); err != nil {
+				return fmt.Errorf("key %s: %w", kv[0], err)
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := parseValue(value, strings.TrimSpace(kv[1]), sep); err != nil {
+				return fmt.Errorf("value of %s: %w", kv[0], err)
+			}
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// splitList splits raw on sep, trimming each item and dropping empty ones
+func splitList(raw string, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, sep) {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,135 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system_test
+
+import (
+	"errors"
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/system"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type database struct {
+	Host     string `env:"HOST" default:"localhost"`
+	Port     uint16 `env:"PORT" default:"5432"`
+	Password string `env:"PASSWORD" required:"true"`
+}
+
+type bindConfig struct {
+	Port     int               `env:"PORT" default:"8080"`
+	Debug    bool              `env:"DEBUG"`
+	Ratio    float64           `env:"RATIO" default:"0.5"`
+	Timeout  time.Duration     `env:"TIMEOUT" default:"30s"`
+	Peers    []string          `env:"PEERS" sep:";"`
+	Ports    []int             `env:"PORTS"`
+	Labels   map[string]string `env:"LABELS"`
+	Bind     net.IP            `env:"BIND" default:"127.0.0.1"`
+	Limit    *int              `env:"LIMIT"`
+	Ignored  string            `env:"-"`
+	Database database          `prefix:"DB_"`
+	Replica  *database         `prefix:"REPLICA_"`
+	internal string
+}
+
+func setEnv(t *testing.T, values map[string]string) func() {
+	for k, v := range values {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return func() {
+		for k := range values {
+			_ = os.Unsetenv(k)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	defer setEnv(t, map[string]string{
+		"APP_DEBUG":            "true",
+		"APP_TIMEOUT":          "1m30s",
+		"APP_PEERS":            "a:1; b:2",
+		"APP_PORTS":            "80,443",
+		"APP_LABELS":           "team=core, tier = web",
+		"APP_LIMIT":            "7",
+		"APP_DB_PORT":          "6543",
+		"APP_DB_PASSWORD":      "hunter2",
+		"APP_REPLICA_PASSWORD": "other",
+	})()
+
+	var cfg bindConfig
+	assert.Nil(system.BindPrefix("APP_", &cfg))
+	assert.Equal(8080, cfg.Port)
+	assert.True(cfg.Debug)
+	assert.Equal(0.5, cfg.Ratio)
+	assert.Equal(90*time.Second, cfg.Timeout)
+	assert.Equal([]string{"a:1", "b:2"}, cfg.Peers)
+	assert.Equal([]int{80, 443}, cfg.Ports)
+	assert.Equal(map[string]string{"team": "core", "tier": "web"}, cfg.Labels)
+	assert.Equal("127.0.0.1", cfg.Bind.String())
+	assert.Equal(7, *cfg.Limit)
+	assert.Equal(database{Host: "localhost", Port: 6543, Password: "hunter2"}, cfg.Database)
+	assert.Equal("other", cfg.Replica.Password)
+}
+
+func TestBind_AllErrors(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	defer setEnv(t, map[string]string{
+		"APP_PORT":    "eighty",
+		"APP_TIMEOUT": "30",
+		"APP_BIND":    "nowhere",
+		"APP_DB_PORT": "70000",
+	})()
+
+	var cfg bindConfig
+	err := system.BindPrefix("APP_", &cfg)
+	assert.NotNil(err)
+
+	var errs system.VarErrors
+	assert.True(errors.As(err, &errs))
+
+	var names []string
+	for _, e := range errs {
+		names = append(names, e.Name)
+	}
+	assert.Equal([]string{"APP_PORT", "APP_TIMEOUT", "APP_BIND", "APP_DB_PORT", "APP_DB_PASSWORD"}, names)
+	assert.True(errors.Is(errs[4], system.ErrNotSet))
+	assert.True(strings.HasPrefix(err.Error(), "5 environment problems:\n\tAPP_PORT: "))
+	assert.Nil(cfg.Replica)
+
+	assert.NotNil(system.Bind(cfg))
+}
+
+func TestBind_OptionalSections(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	env := system.NewEnvironment(system.MapEnv{"APP_DB_PASSWORD": "hunter2"})
+
+	var cfg bindConfig
+	assert.Nil(env.BindPrefix("APP_", &cfg))
+	assert.Nil(cfg.Replica)
+
+	env = system.NewEnvironment(system.MapEnv{"APP_DB_PASSWORD": "hunter2", "APP_REPLICA_HOST": "replica"})
+	cfg = bindConfig{}
+	err := env.BindPrefix("APP_", &cfg)
+	assert.NotNil(err)
+	assert.Equal("APP_REPLICA_PASSWORD: required but not set", err.Error())
+	assert.Equal(database{Host: "replica", Port: 5432}, *cfg.Replica)
+}
@@ -0,0 +1,325 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/threeguys/golang-toolkit/objects"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Struct tags understood by ConfigLoader on top of the ones Bind uses
+const (
+	TagFlag  = "flag"
+	TagUsage = "usage"
+)
+
+// Places a configuration value can come from, in increasing precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Origin says where the effective value of a setting came from. Name is the
+// file and key, variable or flag that set it, and is empty for defaults.
+type Origin struct {
+	Source string
+	Name   string
+}
+
+func (o Origin) String() string {
+	if len(o.Name) == 0 {
+		return o.Source
+	}
+	return o.Source + " " + o.Name
+}
+
+// Origins maps the path of every field of a config struct, like
+// Database.Port, to where its value came from
+type Origins map[string]Origin
+
+// String lists every field and its origin, one per line in field order
+func (o Origins) String() string {
+	paths := make([]string, 0, len(o))
+	for path := range o {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	for _, path := range paths {
+		sb.WriteString(path)
+		sb.WriteString(": ")
+		sb.WriteString(o[path].String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// ConfigLoader fills in a config struct from several layers, each one
+// overriding the last:
+//
+//  1. whatever the struct already holds, or its default tag if that's zero
+//  2. each of Files in turn, JSON objects keyed by field name or json tag
+//  3. variables in Env, or OS.Env if it's nil, named by env tags after
+//     Prefix
+//  4. command line flags named by flag tags, parsed from Args with Flags
+//
+// Strings in JSON files are parsed the same way as variables, so durations
+// can be written "30s", while everything else is decoded by encoding/json.
+// Load registers and parses the flags itself, so it replaces flag.Parse, and
+// fails if Flags was parsed before the first Load or already has a flag by
+// one of the names. Loading again reuses the flags and parses Args again.
+type ConfigLoader struct {
+	Files  []string
+	Env    Env
+	Prefix string
+	Flags  *flag.FlagSet
+	Args   []string
+
+	flags map[string]*settingFlag
+}
+
+// NewConfigLoader returns a loader for the given JSON files that takes flags
+// from the command line
+func NewConfigLoader(files ...string) *ConfigLoader {
+	return &ConfigLoader{
+		Files: files,
+		Flags: flag.CommandLine,
+		Args:  os.Args[1:],
+	}
+}
+
+// Load fills in the struct cfg points to and returns where each field's
+// value came from. Every missing required, unparseable or invalid setting,
+// see Validate, is returned together as VarErrors, while a file that can't
+// be read stops loading.
+func (l *ConfigLoader) Load(cfg interface{}) (Origins, error) {
+	v, err := configStruct(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	found := settings(l.Prefix, v)
+	origins := make(Origins, len(found))
+	var errs VarErrors
+
+	for _, s := range found {
+		origins[s.path] = Origin{Source: SourceDefault}
+		if raw, tagged := s.field.Tag.Lookup(TagDefault); tagged && s.value.IsZero() {
+			if err := s.parse(raw); err != nil {
+				errs = append(errs, &VarError{Name: s.path, Field: s.path, Err: fmt.Errorf("default: %w", err)})
+			}
+		}
+	}
+
+	for _, path := range l.Files {
+		obj, err := objects.NewGenericJsonFromFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, s := range found {
+			raw, present := lookupJson(obj, s.keys)
+			if !present {
+				continue
+			}
+
+			name := path + ":" + strings.Join(s.keys, ".")
+			if err := setJson(s, raw); err != nil {
+				errs = append(errs, &VarError{Name: name, Field: s.path, Err: err})
+			} else {
+				s.attach()
+				origins[s.path] = Origin{Source: SourceFile, Name: name}
+			}
+		}
+	}
+
+	env := l.Env
+	if env == nil {
+		env = OS.Env
+	}
+
+	for _, s := range found {
+		if len(s.env) == 0 {
+			continue
+		}
+
+		raw, present, err := lookupEnv(env, s.env)
+		if err != nil {
+			errs = append(errs, &VarError{Name: s.env, Field: s.path, Err: err})
+		} else if present {
+			if err := s.parse(raw); err != nil {
+				errs = append(errs, &VarError{Name: s.env, Field: s.path, Err: err})
+			} else {
+				s.attach()
+				origins[s.path] = Origin{Source: SourceEnv, Name: s.env}
+			}
+		}
+	}
+
+	if l.Flags != nil {
+		if err := l.parseFlags(found, origins); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range found {
+		if s.required() && origins[s.path].Source == SourceDefault && s.value.IsZero() && !s.detached() {
+			name := s.env
+			if len(name) == 0 {
+				name = s.path
+			}
+			errs = append(errs, &VarError{Name: name, Field: s.path, Err: ErrNotSet})
+		}
+	}
+
+	errs = validateSettings(found, errs, func(s *setting) string {
+		if name := origins[s.path].Name; len(name) > 0 {
+			return name
+		}
+		return settingName(s)
+	}, func(s *setting) bool {
+		return origins[s.path].Source != SourceDefault || !s.value.IsZero()
+	})
+	if len(errs) > 0 {
+		return origins, errs
+	}
+	return origins, nil
+}
+
+func (l *ConfigLoader) parseFlags(found []*setting, origins Origins) error {
+	if l.flags == nil {
+		if l.Flags.Parsed() {
+			return errors.New("flags were parsed before Load so they can't be applied")
+		}
+		l.flags = make(map[string]*settingFlag)
+	}
+
+	// Flags registered by an earlier Load only apply if this struct has them too
+	for _, f := range l.flags {
+		f.s = nil
+	}
+
+	for _, s := range found {
+		name := s.field.Tag.Get(TagFlag)
+		if len(name) == 0 || name == "-" {
+			continue
+		}
+
+		if f, found := l.flags[name]; found {
+			f.s = s
+		} else if l.Flags.Lookup(name) != nil {
+			return fmt.Errorf("flag -%s is already defined", name)
+		} else {
+			l.flags[name] = &settingFlag{s}
+			l.Flags.Var(l.flags[name], name, s.field.Tag.Get(TagUsage))
+		}
+	}
+
+	if err := l.Flags.Parse(l.Args); err != nil {
+		return err
+	}
+
+	l.Flags.Visit(func(f *flag.Flag) {
+		if sf, ok := l.flags[f.Name]; ok && sf.s != nil {
+			origins[sf.s.path] = Origin{Source: SourceFlag, Name: "-" + f.Name}
+		}
+	})
+	return nil
+}
+
+// settingFlag is a flag.Value that parses straight into a setting, which
+// means the defaults shown in usage are the values from the earlier layers.
+// The setting is nil while the flag isn't part of the struct being loaded.
+type settingFlag struct {
+	s *setting
+}
+
+func (f *settingFlag) String() string {
+	if f.s == nil || !f.s.value.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("%v", f.s.value.Interface())
+}
+
+func (f *settingFlag) Set(value string) error {
+	if f.s == nil {
+		return nil
+	}
+	if err := f.s.parse(value); err != nil {
+		return err
+	}
+	f.s.attach()
+	return nil
+}
+
+func (f *settingFlag) IsBoolFlag() bool {
+	return f.s != nil && f.s.value.Kind() == reflect.Bool
+}
+
+// lookupJson follows keys through nested objects
+func lookupJson(obj *objects.GenericJson, keys []string) (interface{}, bool) {
+	if len(keys) == 0 {
+		return nil, false
+	}
+
+	value, found := obj.Lookup(keys[0])
+	if !found {
+		return nil, false
+	}
+
+	for _, key := range keys[1:] {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		next, found := fields[key]
+		if !found {
+			return nil, false
+		}
+		value = next
+	}
+	return value, true
+}
+
+func setJson(s *setting, raw interface{}) error {
+	if str, ok := raw.(string); ok && s.value.Kind() != reflect.String {
+		return s.parse(str)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	target := reflect.New(s.value.Type())
+	if err := json.Unmarshal(data, target.Interface()); err != nil {
+		return err
+	}
+	s.value.Set(target.Elem())
+	return nil
+}
+
@@ -0,0 +1,168 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system_test
+
+import (
+	"errors"
+	"flag"
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/system"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type serverConfig struct {
+	Name     string        `json:"name" env:"NAME" default:"app"`
+	Port     int           `json:"port" env:"PORT" flag:"port" default:"8080"`
+	Timeout  time.Duration `json:"timeout" env:"TIMEOUT"`
+	Verbose  bool          `json:"verbose" flag:"v" usage:"verbose output"`
+	Tags     []string      `json:"tags"`
+	Region   string        `json:"region" required:"true"`
+	Database struct {
+		Host string `json:"host" env:"HOST"`
+		Port int    `json:"port" env:"PORT" default:"5432"`
+	} `json:"database" prefix:"DB_"`
+}
+
+func writeJson(t *testing.T, dir string, name string, data string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigLoader(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "config")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	base := writeJson(t, dir, "base.json", `{"port": 9000, "timeout": "5s", "tags": ["a", "b"], "region": "us-east",
+		"database": {"host": "db.internal", "port": 6000}}`)
+	local := writeJson(t, dir, "local.json", `{"timeout": "10s", "database": {"host": "localhost"}}`)
+	defer setEnv(t, map[string]string{"CFG_TIMEOUT": "1m", "CFG_DB_PORT": "7000"})()
+
+	loader := &system.ConfigLoader{
+		Files:  []string{base, local},
+		Prefix: "CFG_",
+		Flags:  flag.NewFlagSet("test", flag.ContinueOnError),
+		Args:   []string{"-port", "9443", "-v"},
+	}
+
+	cfg := serverConfig{Name: "explicit"}
+	origins, err := loader.Load(&cfg)
+	assert.Nil(err)
+
+	assert.Equal("explicit", cfg.Name)
+	assert.Equal(9443, cfg.Port)
+	assert.Equal(time.Minute, cfg.Timeout)
+	assert.True(cfg.Verbose)
+	assert.Equal([]string{"a", "b"}, cfg.Tags)
+	assert.Equal("localhost", cfg.Database.Host)
+	assert.Equal(7000, cfg.Database.Port)
+
+	assert.Equal(system.Origin{Source: system.SourceDefault}, origins["Name"])
+	assert.Equal(system.Origin{Source: system.SourceFlag, Name: "-port"}, origins["Port"])
+	assert.Equal(system.Origin{Source: system.SourceEnv, Name: "CFG_TIMEOUT"}, origins["Timeout"])
+	assert.Equal(system.Origin{Source: system.SourceFile, Name: base + ":tags"}, origins["Tags"])
+	assert.Equal(system.Origin{Source: system.SourceFile, Name: local + ":database.host"}, origins["Database.Host"])
+	assert.Equal("flag -v", origins["Verbose"].String())
+}
+
+func TestConfigLoader_Errors(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "config")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := writeJson(t, dir, "bad.json", `{"port": "eighty", "tags": 7}`)
+	defer setEnv(t, map[string]string{"BAD_DB_PORT": "x"})()
+
+	loader := &system.ConfigLoader{Files: []string{path}, Prefix: "BAD_"}
+	var cfg serverConfig
+	_, err = loader.Load(&cfg)
+
+	var errs system.VarErrors
+	assert.True(errors.As(err, &errs))
+	assert.Equal(4, len(errs))
+	assert.Equal(path+":port", errs[0].Name)
+	assert.Equal(path+":tags", errs[1].Name)
+	assert.Equal("BAD_DB_PORT", errs[2].Name)
+	assert.Equal("Region", errs[3].Name)
+	assert.True(errors.Is(errs[3], system.ErrNotSet))
+
+	loader.Files = []string{filepath.Join(dir, "missing.json")}
+	_, err = loader.Load(&cfg)
+	assert.True(errors.Is(err, os.ErrNotExist))
+}
+
+func TestConfigLoader_OptionalSections(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	type tlsConfig struct {
+		Cert string `json:"cert" env:"CERT" required:"true"`
+		Key  string `json:"key" env:"KEY" required:"true"`
+	}
+	type config struct {
+		TLS *tlsConfig `json:"tls" prefix:"TLS_"`
+	}
+
+	var cfg config
+	_, err := (&system.ConfigLoader{Env: system.MapEnv{}}).Load(&cfg)
+	assert.Nil(err)
+	assert.Nil(cfg.TLS)
+
+	_, err = (&system.ConfigLoader{Env: system.MapEnv{"TLS_CERT": "cert.pem"}}).Load(&cfg)
+	assert.NotNil(err)
+	assert.Equal("TLS_KEY: required but not set", err.Error())
+	assert.Equal("cert.pem", cfg.TLS.Cert)
+}
+
+func TestConfigLoader_Flags(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	type config struct {
+		Port    int  `flag:"port"`
+		Verbose bool `flag:"v"`
+	}
+
+	loader := &system.ConfigLoader{
+		Env:   system.MapEnv{},
+		Flags: flag.NewFlagSet("test", flag.ContinueOnError),
+		Args:  []string{"-port", "9443", "-v"},
+	}
+
+	for i := 0; i < 2; i++ {
+		var cfg config
+		origins, err := loader.Load(&cfg)
+		assert.Nil(err)
+		assert.Equal(config{Port: 9443, Verbose: true}, cfg)
+		assert.Equal("flag -port", origins["Port"].String())
+	}
+
+	clash := flag.NewFlagSet("test", flag.ContinueOnError)
+	clash.Int("port", 0, "already here")
+	_, err := (&system.ConfigLoader{Env: system.MapEnv{}, Flags: clash}).Load(&config{})
+	assert.NotNil(err)
+	assert.Equal("flag -port is already defined", err.Error())
+
+	parsed := flag.NewFlagSet("test", flag.ContinueOnError)
+	assert.Nil(parsed.Parse(nil))
+	_, err = (&system.ConfigLoader{Env: system.MapEnv{}, Flags: parsed}).Load(&config{})
+	assert.NotNil(err)
+}
@@ -0,0 +1,366 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultDotEnvFile is read when no paths are given
+const DefaultDotEnvFile = ".env"
+
+// DotEnvPolicy decides what LoadDotEnv does with variables that are already
+// set in the environment
+type DotEnvPolicy int
+
+const (
+	// KeepExisting leaves variables that are already set alone, so the real
+	// environment wins over the file
+	KeepExisting DotEnvPolicy = iota
+	// OverrideExisting replaces variables that are already set
+	OverrideExisting
+)
+
+// ParseDotEnv reads variables in .env format:
+//
+//	# comments on their own line or after unquoted values
+//	export NAME=value
+//	GREETING="hello\tworld\n"   # escapes are expanded in double quotes
+//	LITERAL='nothing $HERE is expanded'
+//	URL=http://${HOST}:${PORT:-8080}/
+//	KEY="-----BEGIN KEY-----
+//	quoted values can span lines
+//	-----END KEY-----"
+//
+// References to other variables, ${NAME}, $NAME or ${NAME:-default}, are
+// expanded in unquoted and double quoted values using the values read so
+// far and then OS.Env. Missing ones expand to nothing.
+func ParseDotEnv(r io.Reader) (map[string]string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newDotEnvParser(string(data), OS.Env, false)
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.values, nil
+}
+
+// ReadDotEnv parses each file in turn, DefaultDotEnvFile if there are none,
+// without touching the environment. Later files override earlier ones.
+func ReadDotEnv(paths ...string) (map[string]string, error) {
+	return OS.ReadDotEnv(paths...)
+}
+
+// ReadDotEnv is ReadDotEnv with references to variables not in the files
+// expanded from Env. The result can be layered over Env with a MapEnv.
+func (e *Environment) ReadDotEnv(paths ...string) (map[string]string, error) {
+	return readDotEnv(e.Env, false, paths)
+}
+
+// LoadDotEnv parses each file in turn, DefaultDotEnvFile if there are none,
+// and sets the variables in the environment. With KeepExisting a variable
+// already in the environment is also what references to it expand to.
+func LoadDotEnv(policy DotEnvPolicy, paths ...string) error {
+	values, err := readDotEnv(OS.Env, policy == KeepExisting, paths)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, found, _ := OS.lookup(name); found && policy == KeepExisting {
+			continue
+		}
+		if err := os.Setenv(name, values[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readDotEnv(env Env, preferEnv bool, paths []string) (map[string]string, error) {
+	if len(paths) == 0 {
+		paths = []string{DefaultDotEnvFile}
+	}
+
+	values := make(map[string]string)
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		p := newDotEnvParser(string(data), env, preferEnv)
+		p.values = values
+		if err := p.parse(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return values, nil
+}
+
+type dotEnvParser struct {
+	data      string
+	pos       int
+	line      int
+	env       Env
+	preferEnv bool
+	values    map[string]string
+}
+
+func newDotEnvParser(data string, env Env, preferEnv bool) *dotEnvParser {
+	return &dotEnvParser{
+		data:      strings.Replace(data, "\r\n", "\n", -1),
+		line:      1,
+		env:       env,
+		preferEnv: preferEnv,
+		values:    make(map[string]string),
+	}
+}
+
+func (p *dotEnvParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotEnvParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *dotEnvParser) peek() byte {
+	return p.data[p.pos]
+}
+
+func (p *dotEnvParser) next() byte {
+	c := p.data[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *dotEnvParser) skipBlanks() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *dotEnvParser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func (p *dotEnvParser) parse() error {
+	for {
+		for !p.eof() && strings.IndexByte(" \t\n", p.peek()) >= 0 {
+			p.next()
+		}
+		if p.eof() {
+			return nil
+		} else if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		name := p.name(true)
+		if name == "export" && !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+			p.skipBlanks()
+			name = p.name(true)
+		}
+		if len(name) == 0 {
+			return p.errorf("expected a variable name")
+		}
+
+		p.skipBlanks()
+		if p.eof() || p.peek() != '=' {
+			return p.errorf("expected = after %s", name)
+		}
+		p.next()
+		p.skipBlanks()
+
+		value, err := p.value(name)
+		if err != nil {
+			return err
+		}
+		p.values[name] = value
+	}
+}
+
+// name reads a variable name, dotted allows dots after the first character
+// as in app.port=80, while references stop at them so $HOST.local works
+func (p *dotEnvParser) name(dotted bool) string {
+	start := p.pos
+	for !p.eof() && isNameChar(p.peek(), p.pos == start, dotted) {
+		p.pos++
+	}
+	return p.data[start:p.pos]
+}
+
+func isNameChar(c byte, first bool, dotted bool) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') ||
+		(!first && ((dotted && c == '.') || (c >= '0' && c <= '9')))
+}
+
+func (p *dotEnvParser) value(name string) (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+
+	quote := p.peek()
+	if quote != '"' && quote != '\'' {
+		return p.unquoted()
+	}
+
+	start := p.line
+	p.next()
+
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			p.line = start
+			return "", p.errorf("unterminated quoted value for %s", name)
+		}
+
+		c := p.next()
+		switch {
+		case c == quote:
+			p.skipBlanks()
+			if !p.eof() && p.peek() == '#' {
+				p.skipLine()
+			} else if !p.eof() && p.peek() != '\n' {
+				return "", p.errorf("unexpected characters after quoted value for %s", name)
+			}
+			return sb.String(), nil
+
+		case quote == '"' && c == '\\' && !p.eof():
+			sb.WriteString(unescape(p.next()))
+
+		case quote == '"' && c == '$':
+			value, err := p.expand()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(value)
+
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+func unescape(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\', '$', '\'':
+		return string(c)
+	}
+	return "\\" + string(c)
+}
+
+// unquoted reads to the end of the line or a comment, a # after whitespace
+func (p *dotEnvParser) unquoted() (string, error) {
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.next()
+		if c == '\n' {
+			break
+		} else if c == '#' && (sb.Len() == 0 || strings.IndexByte(" \t", p.data[p.pos-2]) >= 0) {
+			p.skipLine()
+			break
+		} else if c == '$' {
+			value, err := p.expand()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(value)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// expand reads the reference after a $ and returns its value, a $ that
+// isn't followed by a name is left alone. A ${ has to be closed on the same
+// line.
+func (p *dotEnvParser) expand() (string, error) {
+	if p.eof() {
+		return "$", nil
+	}
+
+	if p.peek() != '{' {
+		name := p.name(false)
+		if len(name) == 0 {
+			return "$", nil
+		}
+		value, _ := p.lookup(name)
+		return value, nil
+	}
+
+	line := p.data[p.pos:]
+	if newline := strings.IndexByte(line, '\n'); newline >= 0 {
+		line = line[:newline]
+	}
+
+	end := strings.IndexByte(line, '}')
+	if end < 0 {
+		return "", p.errorf("unterminated ${ reference")
+	}
+
+	ref := p.data[p.pos+1 : p.pos+end]
+	p.pos += end + 1
+
+	name, fallback := ref, ""
+	if idx := strings.Index(ref, ":-"); idx >= 0 {
+		name, fallback = ref[:idx], ref[idx+2:]
+	}
+
+	if value, found := p.lookup(name); found && len(value) > 0 {
+		return value, nil
+	}
+	return fallback, nil
+}
+
+func (p *dotEnvParser) lookup(name string) (string, bool) {
+	if p.preferEnv {
+		if value, found := p.env.LookupEnv(name); found {
+			return value, true
+		}
+	}
+
+	if value, found := p.values[name]; found {
+		return value, true
+	}
+	return p.env.LookupEnv(name)
+}
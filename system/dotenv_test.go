@@ -0,0 +1,112 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system_test
+
+import (
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/system"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleDotEnv = `# local development settings
+export HOST=db.local
+PORT = 5432   # inline comment
+EMPTY=
+URL=postgres://${HOST}:${PORT}/app?ssl=${SSL:-off}
+GREETING="hello\tworld\n\"quoted\" \$HOST $HOST"  # comment
+LITERAL='no ${HOST} or \n here'
+HASH=abc#def
+FQDN=$HOST.internal
+app.port=$PORT
+KEY="-----BEGIN KEY-----
+line two
+-----END KEY-----"
+MULTI='first
+second'
+`
+
+func TestParseDotEnv(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	values, err := system.ParseDotEnv(strings.NewReader(sampleDotEnv))
+	assert.Nil(err)
+	assert.Equal(map[string]string{
+		"HOST":     "db.local",
+		"FQDN":     "db.local.internal",
+		"app.port": "5432",
+		"PORT":     "5432",
+		"EMPTY":    "",
+		"URL":      "postgres://db.local:5432/app?ssl=off",
+		"GREETING": "hello\tworld\n\"quoted\" $HOST db.local",
+		"LITERAL":  "no ${HOST} or \\n here",
+		"HASH":     "abc#def",
+		"KEY":      "-----BEGIN KEY-----\nline two\n-----END KEY-----",
+		"MULTI":    "first\nsecond",
+	}, values)
+}
+
+func TestParseDotEnv_Errors(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	for input, expected := range map[string]string{
+		"A=1\nB\n":            "line 2: expected = after B",
+		"A=1\n\nB=\"open\n":   "line 3: unterminated quoted value for B",
+		"A='x' y\n":           "line 1: unexpected characters after quoted value for A",
+		"A=1\n9LIVES=cat\n":   "line 2: expected a variable name",
+		"C=${MISSING\nD=x}\n": "line 1: unterminated ${ reference",
+		"A=1\nB=\"${X\n}\"\n": "line 2: unterminated ${ reference",
+	} {
+		_, err := system.ParseDotEnv(strings.NewReader(input))
+		assert.NotNil(err)
+		assert.Equal(expected, err.Error())
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "dotenv")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	assert.Nil(ioutil.WriteFile(base, []byte("DOTENV_HOST=file\nDOTENV_URL=http://${DOTENV_HOST}\nDOTENV_MODE=base\n"), 0600))
+	assert.Nil(ioutil.WriteFile(local, []byte("DOTENV_MODE=local\n"), 0600))
+
+	values, err := system.ReadDotEnv(base, local)
+	assert.Nil(err)
+	assert.Equal("local", values["DOTENV_MODE"])
+	_, found := os.LookupEnv("DOTENV_MODE")
+	assert.False(found)
+
+	defer setEnv(t, map[string]string{"DOTENV_HOST": "real", "DOTENV_URL": "", "DOTENV_MODE": ""})()
+	os.Unsetenv("DOTENV_URL")
+	os.Unsetenv("DOTENV_MODE")
+
+	assert.Nil(system.LoadDotEnv(system.KeepExisting, base))
+	assert.Equal("real", os.Getenv("DOTENV_HOST"))
+	assert.Equal("http://real", os.Getenv("DOTENV_URL"))
+
+	assert.Nil(system.LoadDotEnv(system.OverrideExisting, base, local))
+	assert.Equal("file", os.Getenv("DOTENV_HOST"))
+	assert.Equal("http://file", os.Getenv("DOTENV_URL"))
+	assert.Equal("local", os.Getenv("DOTENV_MODE"))
+
+	err = system.LoadDotEnv(system.KeepExisting, filepath.Join(dir, "missing"))
+	assert.True(os.IsNotExist(err))
+}
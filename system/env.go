@@ -0,0 +1,225 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Env is somewhere environment variables are looked up
+type Env interface {
+	LookupEnv(name string) (string, bool)
+}
+
+// CheckedEnv is an Env whose lookups can fail, like SecretsEnv reading a
+// file. Environment reports those failures instead of treating the variable
+// as missing.
+type CheckedEnv interface {
+	Env
+	LookupEnvChecked(name string) (string, bool, error)
+}
+
+// lookupEnv looks name up in env, checking for errors if it can fail
+func lookupEnv(env Env, name string) (string, bool, error) {
+	if checked, ok := env.(CheckedEnv); ok {
+		return checked.LookupEnvChecked(name)
+	}
+	value, found := env.LookupEnv(name)
+	return value, found, nil
+}
+
+// OsEnv is the environment of the process
+type OsEnv struct{}
+
+func (OsEnv) LookupEnv(name string) (string, bool) {
+	return os.LookupEnv(name)
+}
+
+// MapEnv is an environment held in a map, which tests can run against in
+// parallel without touching the process environment
+type MapEnv map[string]string
+
+func (m MapEnv) LookupEnv(name string) (string, bool) {
+	value, found := m[name]
+	return value, found
+}
+
+// PrefixedEnv looks up every name in Env with Prefix prepended, so PORT in
+// a PrefixedEnv with the prefix APP_ is APP_PORT
+type PrefixedEnv struct {
+	Prefix string
+	Env    Env
+}
+
+func NewPrefixedEnv(prefix string, env Env) PrefixedEnv {
+	return PrefixedEnv{
+		Prefix: prefix,
+		Env:    env,
+	}
+}
+
+func (p PrefixedEnv) LookupEnv(name string) (string, bool) {
+	return p.Env.LookupEnv(p.Prefix + name)
+}
+
+func (p PrefixedEnv) LookupEnvChecked(name string) (string, bool, error) {
+	return lookupEnv(p.Env, p.Prefix+name)
+}
+
+// LayeredEnv looks a name up in each of its environments in turn and returns
+// the first value found
+type LayeredEnv []Env
+
+func NewLayeredEnv(envs ...Env) LayeredEnv {
+	return LayeredEnv(envs)
+}
+
+func (l LayeredEnv) LookupEnv(name string) (string, bool) {
+	for _, env := range l {
+		if value, found := env.LookupEnv(name); found {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (l LayeredEnv) LookupEnvChecked(name string) (string, bool, error) {
+	for _, env := range l {
+		if value, found, err := lookupEnv(env, name); found || err != nil {
+			return value, found, err
+		}
+	}
+	return "", false, nil
+}
+
+// Environment has every helper in this package as a method, reading from Env
+// instead of the process environment. The package level functions all use OS.
+type Environment struct {
+	Env Env
+}
+
+// OS is the Environment of the process. Its Env reads from OsEnv unless
+// UseEnv has replaced it, and ConfigLoader and the .env helpers read through
+// it too.
+var OS = NewEnvironment(processEnv)
+
+var processEnv = &switchableEnv{}
+
+// UseEnv makes OS read from env, which should wrap OsEnv rather than OS.Env.
+// A nil env goes back to OsEnv.
+//
+//	system.UseEnv(system.NewSecretsEnv(system.OsEnv{}))
+func UseEnv(env Env) {
+	processEnv.lock.Lock()
+	defer processEnv.lock.Unlock()
+	processEnv.env = env
+}
+
+// switchableEnv passes lookups on to an Env that can be replaced safely
+// while other goroutines are reading from it
+type switchableEnv struct {
+	lock sync.RWMutex
+	env  Env
+}
+
+func (s *switchableEnv) current() Env {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.env == nil {
+		return OsEnv{}
+	}
+	return s.env
+}
+
+func (s *switchableEnv) LookupEnv(name string) (string, bool) {
+	return s.current().LookupEnv(name)
+}
+
+func (s *switchableEnv) LookupEnvChecked(name string) (string, bool, error) {
+	return lookupEnv(s.current(), name)
+}
+
+func NewEnvironment(env Env) *Environment {
+	return &Environment{
+		Env: env,
+	}
+}
+
+// lookup finds a variable, failing if Env is a CheckedEnv that can't read it
+func (e *Environment) lookup(name string) (string, bool, error) {
+	return lookupEnv(e.Env, name)
+}
+
+func (e *Environment) OrDefault(name string, defaultValue string) string {
+	if value, found, err := e.lookup(name); found && err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// OrDefaultDuration reads a duration in Go syntax like 30s, returning
+// defaultValue if it's missing or invalid
+func (e *Environment) OrDefaultDuration(name string, defaultValue time.Duration) time.Duration {
+	if d, err := e.Duration(name); err == nil {
+		return d
+	}
+	return defaultValue
+}
+
+// OrDefaultInt returns defaultValue if the variable is missing or isn't an
+// integer
+func (e *Environment) OrDefaultInt(name string, defaultValue int) int {
+	if n, err := e.Int(name); err == nil {
+		return n
+	}
+	return defaultValue
+}
+
+func (e *Environment) Required(name string) (string, error) {
+	value, found, err := e.lookup(name)
+	if err != nil {
+		return "", err
+	} else if found {
+		return value, nil
+	}
+	return "", fmt.Errorf("Environment %s was missing", name)
+}
+
+// MapRequired reads every one of names, returning all the ones that are
+// missing or unreadable together as VarErrors
+func (e *Environment) MapRequired(names []string) (map[string]string, error) {
+	values := make(map[string]string)
+	var errs VarErrors
+	for _, n := range names {
+		v, found, err := e.lookup(n)
+		if err != nil {
+			errs = append(errs, &VarError{Name: n, Err: err})
+		} else if !found {
+			errs = append(errs, &VarError{Name: n, Err: ErrNotSet})
+		} else {
+			values[n] = v
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return values, nil
+}
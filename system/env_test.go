@@ -0,0 +1,144 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system_test
+
+import (
+	"errors"
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/system"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvImplementations(t *testing.T) {
+	t.Parallel()
+	assert := objects.NewTestAssertions(t)
+
+	base := system.MapEnv{"APP_PORT": "8080", "APP_HOST": "base", "OTHER": "x"}
+	overrides := system.MapEnv{"APP_HOST": "override"}
+	env := system.NewPrefixedEnv("APP_", system.NewLayeredEnv(overrides, base))
+
+	host, found := env.LookupEnv("HOST")
+	assert.True(found)
+	assert.Equal("override", host)
+
+	port, found := env.LookupEnv("PORT")
+	assert.True(found)
+	assert.Equal("8080", port)
+
+	_, found = env.LookupEnv("OTHER")
+	assert.False(found)
+
+	_, found = system.NewLayeredEnv().LookupEnv("PORT")
+	assert.False(found)
+}
+
+func TestEnvironment(t *testing.T) {
+	t.Parallel()
+	assert := objects.NewTestAssertions(t)
+	env := system.NewEnvironment(system.MapEnv{
+		"PORT":    "9000",
+		"TIMEOUT": "45s",
+		"PEERS":   "a,b",
+		"SIZE":    "1KiB",
+		"NAME":    "svc",
+	})
+
+	assert.Equal("svc", env.OrDefault("NAME", "x"))
+	assert.Equal("x", env.OrDefault("MISSING", "x"))
+	assert.Equal(9000, env.OrDefaultInt("PORT", 1))
+	assert.Equal(45*time.Second, env.OrDefaultDuration("TIMEOUT", 0))
+	assert.Equal([]string{"a", "b"}, env.OrDefaultList("PEERS", ",", nil))
+	assert.Equal(system.ByteSize(1024), env.OrDefaultBytes("SIZE", 0))
+
+	values, err := env.MapRequired([]string{"PORT", "NAME"})
+	assert.Nil(err)
+	assert.Equal(map[string]string{"PORT": "9000", "NAME": "svc"}, values)
+	_, err = env.Required("MISSING")
+	assert.Equal("Environment MISSING was missing", err.Error())
+
+	var cfg struct {
+		Port    int           `env:"PORT"`
+		Timeout time.Duration `env:"TIMEOUT"`
+		Region  string        `env:"REGION" required:"true"`
+	}
+	err = env.Bind(&cfg)
+	assert.True(errors.Is(err, system.ErrNotSet))
+	assert.Equal(9000, cfg.Port)
+	assert.Equal(45*time.Second, cfg.Timeout)
+}
+
+func TestEnvironment_ConfigAndDotEnv(t *testing.T) {
+	t.Parallel()
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "env")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".env")
+	assert.Nil(ioutil.WriteFile(path, []byte("URL=http://${HOST}:${PORT}\n"), 0600))
+
+	env := system.NewEnvironment(system.MapEnv{"HOST": "example.com", "PORT": "81"})
+	values, err := env.ReadDotEnv(path)
+	assert.Nil(err)
+	assert.Equal("http://example.com:81", values["URL"])
+
+	var cfg struct {
+		URL  string `env:"URL"`
+		Port int    `env:"PORT"`
+	}
+	loader := &system.ConfigLoader{Env: system.NewLayeredEnv(system.MapEnv(values), env.Env)}
+	origins, err := loader.Load(&cfg)
+	assert.Nil(err)
+	assert.Equal("http://example.com:81", cfg.URL)
+	assert.Equal(81, cfg.Port)
+	assert.Equal("env PORT", origins["Port"].String())
+}
+
+func TestUseEnv(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	defer system.UseEnv(nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			system.EnvOrDefault("USE_ENV_NAME", "")
+		}
+	}()
+
+	system.UseEnv(system.LayeredEnv{system.MapEnv{"USE_ENV_NAME": "swapped", "USE_ENV_PORT": "9000"}, system.OsEnv{}})
+	<-done
+	assert.Equal("swapped", system.EnvOrDefault("USE_ENV_NAME", ""))
+
+	var cfg struct {
+		Port int `env:"USE_ENV_PORT"`
+	}
+	_, err := (&system.ConfigLoader{}).Load(&cfg)
+	assert.Nil(err)
+	assert.Equal(9000, cfg.Port)
+
+	values, err := system.ParseDotEnv(strings.NewReader("URL=http://$USE_ENV_NAME\n"))
+	assert.Nil(err)
+	assert.Equal("http://swapped", values["URL"])
+
+	system.UseEnv(nil)
+	assert.Equal("", system.EnvOrDefault("USE_ENV_NAME", ""))
+}
@@ -16,53 +16,110 @@
 package system
 
 import (
-    "errors"
-    "fmt"
-    "os"
-    "strconv"
+    "net/url"
     "time"
 )
 
 func EnvOrDefault(name string, defaultValue string) string {
-    if value, found := os.LookupEnv(name); found {
-        return value
-    }
-
-    return defaultValue
+    return OS.OrDefault(name, defaultValue)
 }
 
+// EnvOrDefaultDuration reads a duration in Go syntax like 30s, returning
+// defaultValue if it's missing or invalid
 func EnvOrDefaultDuration(name string, defaultValue time.Duration) time.Duration {
-    return time.Duration(EnvOrDefaultInt(name, int(defaultValue)))
+    return OS.OrDefaultDuration(name, defaultValue)
 }
 
+// EnvOrDefaultInt returns defaultValue if the variable is missing or isn't
+// an integer
 func EnvOrDefaultInt(name string, defaultValue int) int {
-    if value, found := os.LookupEnv(name); found {
-        intValue, err := strconv.Atoi(value)
-        if err == nil {
-            return intValue
-        } else {
-            panic(err)
-        }
-    }
-    return defaultValue
+    return OS.OrDefaultInt(name, defaultValue)
 }
 
 func EnvRequired(name string) (string, error) {
-    if value, found := os.LookupEnv(name); found {
-        return value, nil
-    }
-
-    return "", errors.New(fmt.Sprintf("Environment %s was missing", name))
+    return OS.Required(name)
 }
 
 func EnvMapRequired(names []string) (map[string]string, error) {
-    values := make(map[string]string)
-    for _, n := range names {
-        v, err := EnvRequired(n)
-        if err != nil {
-            return nil, err
-        }
-        values[n] = v
-    }
-    return values, nil
+    return OS.MapRequired(names)
+}
+
+func EnvInt(name string) (int, error) {
+    return OS.Int(name)
+}
+
+func EnvInt64(name string) (int64, error) {
+    return OS.Int64(name)
+}
+
+func EnvOrDefaultInt64(name string, defaultValue int64) int64 {
+    return OS.OrDefaultInt64(name, defaultValue)
+}
+
+func EnvUint(name string) (uint64, error) {
+    return OS.Uint(name)
+}
+
+func EnvOrDefaultUint(name string, defaultValue uint64) uint64 {
+    return OS.OrDefaultUint(name, defaultValue)
+}
+
+func EnvFloat(name string) (float64, error) {
+    return OS.Float(name)
+}
+
+func EnvOrDefaultFloat(name string, defaultValue float64) float64 {
+    return OS.OrDefaultFloat(name, defaultValue)
+}
+
+// EnvBool accepts the values strconv.ParseBool does: 1, t, true, 0, f, false
+// and so on
+func EnvBool(name string) (bool, error) {
+    return OS.Bool(name)
+}
+
+func EnvOrDefaultBool(name string, defaultValue bool) bool {
+    return OS.OrDefaultBool(name, defaultValue)
+}
+
+// EnvDuration reads a duration in Go syntax like 30s or 1h15m
+func EnvDuration(name string) (time.Duration, error) {
+    return OS.Duration(name)
+}
+
+// EnvURL reads an absolute URL
+func EnvURL(name string) (*url.URL, error) {
+    return OS.URL(name)
+}
+
+func EnvOrDefaultURL(name string, defaultValue *url.URL) *url.URL {
+    return OS.OrDefaultURL(name, defaultValue)
+}
+
+// EnvList splits a variable on sep, trimming each item and dropping empty
+// ones
+func EnvList(name string, sep string) ([]string, error) {
+    return OS.List(name, sep)
+}
+
+func EnvOrDefaultList(name string, sep string, defaultValue []string) []string {
+    return OS.OrDefaultList(name, sep, defaultValue)
+}
+
+// EnvMap reads key=value pairs separated by sep, like team=core,tier=web
+func EnvMap(name string, sep string) (map[string]string, error) {
+    return OS.Map(name, sep)
+}
+
+func EnvOrDefaultMap(name string, sep string, defaultValue map[string]string) map[string]string {
+    return OS.OrDefaultMap(name, sep, defaultValue)
+}
+
+// EnvBytes reads a byte size like 512, 64KB or 10MiB, see ParseByteSize
+func EnvBytes(name string) (ByteSize, error) {
+    return OS.Bytes(name)
+}
+
+func EnvOrDefaultBytes(name string, defaultValue ByteSize) ByteSize {
+    return OS.OrDefaultBytes(name, defaultValue)
 }
@@ -0,0 +1,298 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ByteSize is a number of bytes, written with an optional unit like 512,
+// 64KB or 10MiB. It can be used as a field type with Bind.
+type ByteSize int64
+
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1000,
+	"kb":  1000,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1000 * 1000,
+	"mb":  1000 * 1000,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1000 * 1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1000 * 1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"p":   1000 * 1000 * 1000 * 1000 * 1000,
+	"pb":  1000 * 1000 * 1000 * 1000 * 1000,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+}
+
+// ParseByteSize parses a number of bytes with an optional unit, ignoring
+// case. KB, MB and so on are powers of 1000 while KiB, MiB and so on are
+// powers of 1024. Fractions are allowed, 1.5GiB, and rounded down.
+func ParseByteSize(value string) (ByteSize, error) {
+	value = strings.TrimSpace(value)
+	end := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end < 0 {
+		end = len(value)
+	}
+
+	number := value[:end]
+	unit, found := byteUnits[strings.ToLower(strings.TrimSpace(value[end:]))]
+	if len(number) == 0 || !found {
+		return 0, fmt.Errorf("invalid byte size %q", value)
+	}
+
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		if n > math.MaxInt64/unit {
+			return 0, fmt.Errorf("byte size %q is too large", value)
+		}
+		return ByteSize(n * unit), nil
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", value)
+	} else if f*float64(unit) >= math.MaxInt64 {
+		return 0, fmt.Errorf("byte size %q is too large", value)
+	}
+	return ByteSize(f * float64(unit)), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// parseDuration accepts Go duration syntax, 30s or 1h15m
+func parseDuration(value string) (time.Duration, error) {
+	return time.ParseDuration(strings.TrimSpace(value))
+}
+
+// parseURL only accepts absolute URLs, anything without a scheme and host is
+// almost certainly a mistake in configuration
+func parseURL(value string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return nil, err
+	} else if len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return nil, errors.New("URL must include a scheme and host")
+	}
+	return u, nil
+}
+
+// parseMap splits value into key=value pairs separated by sep
+func parseMap(value string, sep string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, item := range splitList(value, sep) {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%q is not a key=value pair", item)
+		}
+		m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return m, nil
+}
+
+// lookupParsed hands the named variable to parse, returning a VarError if
+// it's missing or parse fails
+func (e *Environment) lookupParsed(name string, parse func(value string) error) error {
+	value, found, err := e.lookup(name)
+	if err != nil {
+		return &VarError{Name: name, Err: err}
+	} else if !found {
+		return &VarError{Name: name, Err: ErrNotSet}
+	}
+
+	if err := parse(value); err != nil {
+		return &VarError{Name: name, Err: err}
+	}
+	return nil
+}
+
+func (e *Environment) Int(name string) (int, error) {
+	var n int64
+	err := e.lookupParsed(name, func(value string) (err error) {
+		n, err = strconv.ParseInt(strings.TrimSpace(value), 10, strconv.IntSize)
+		return
+	})
+	return int(n), err
+}
+
+func (e *Environment) Int64(name string) (int64, error) {
+	var n int64
+	err := e.lookupParsed(name, func(value string) (err error) {
+		n, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		return
+	})
+	return n, err
+}
+
+func (e *Environment) OrDefaultInt64(name string, defaultValue int64) int64 {
+	if n, err := e.Int64(name); err == nil {
+		return n
+	}
+	return defaultValue
+}
+
+func (e *Environment) Uint(name string) (uint64, error) {
+	var n uint64
+	err := e.lookupParsed(name, func(value string) (err error) {
+		n, err = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		return
+	})
+	return n, err
+}
+
+func (e *Environment) OrDefaultUint(name string, defaultValue uint64) uint64 {
+	if n, err := e.Uint(name); err == nil {
+		return n
+	}
+	return defaultValue
+}
+
+func (e *Environment) Float(name string) (float64, error) {
+	var f float64
+	err := e.lookupParsed(name, func(value string) (err error) {
+		f, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return
+	})
+	return f, err
+}
+
+func (e *Environment) OrDefaultFloat(name string, defaultValue float64) float64 {
+	if f, err := e.Float(name); err == nil {
+		return f
+	}
+	return defaultValue
+}
+
+// Bool accepts the values strconv.ParseBool does: 1, t, true, 0, f, false
+// and so on
+func (e *Environment) Bool(name string) (bool, error) {
+	var b bool
+	err := e.lookupParsed(name, func(value string) (err error) {
+		b, err = strconv.ParseBool(strings.TrimSpace(value))
+		return
+	})
+	return b, err
+}
+
+func (e *Environment) OrDefaultBool(name string, defaultValue bool) bool {
+	if b, err := e.Bool(name); err == nil {
+		return b
+	}
+	return defaultValue
+}
+
+// Duration reads a duration in Go syntax like 30s or 1h15m
+func (e *Environment) Duration(name string) (time.Duration, error) {
+	var d time.Duration
+	err := e.lookupParsed(name, func(value string) (err error) {
+		d, err = parseDuration(value)
+		return
+	})
+	return d, err
+}
+
+// URL reads an absolute URL
+func (e *Environment) URL(name string) (*url.URL, error) {
+	var u *url.URL
+	err := e.lookupParsed(name, func(value string) (err error) {
+		u, err = parseURL(value)
+		return
+	})
+	return u, err
+}
+
+func (e *Environment) OrDefaultURL(name string, defaultValue *url.URL) *url.URL {
+	if u, err := e.URL(name); err == nil {
+		return u
+	}
+	return defaultValue
+}
+
+// List splits a variable on sep, trimming each item and dropping empty
+// ones
+func (e *Environment) List(name string, sep string) ([]string, error) {
+	var items []string
+	err := e.lookupParsed(name, func(value string) error {
+		items = splitList(value, sep)
+		return nil
+	})
+	return items, err
+}
+
+func (e *Environment) OrDefaultList(name string, sep string, defaultValue []string) []string {
+	if items, err := e.List(name, sep); err == nil {
+		return items
+	}
+	return defaultValue
+}
+
+// Map reads key=value pairs separated by sep, like team=core,tier=web
+func (e *Environment) Map(name string, sep string) (map[string]string, error) {
+	var m map[string]string
+	err := e.lookupParsed(name, func(value string) (err error) {
+		m, err = parseMap(value, sep)
+		return
+	})
+	return m, err
+}
+
+func (e *Environment) OrDefaultMap(name string, sep string, defaultValue map[string]string) map[string]string {
+	if m, err := e.Map(name, sep); err == nil {
+		return m
+	}
+	return defaultValue
+}
+
+// Bytes reads a byte size like 512, 64KB or 10MiB, see ParseByteSize
+func (e *Environment) Bytes(name string) (ByteSize, error) {
+	var b ByteSize
+	err := e.lookupParsed(name, func(value string) (err error) {
+		b, err = ParseByteSize(value)
+		return
+	})
+	return b, err
+}
+
+func (e *Environment) OrDefaultBytes(name string, defaultValue ByteSize) ByteSize {
+	if b, err := e.Bytes(name); err == nil {
+		return b
+	}
+	return defaultValue
+}
@@ -0,0 +1,113 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system_test
+
+import (
+	"errors"
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/system"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	for value, expected := range map[string]system.ByteSize{
+		"512":     512,
+		"64KB":    64000,
+		"10MiB":   10 << 20,
+		"10 mib":  10 << 20,
+		"1.5GiB":  3 << 29,
+		"2Gi":     2 << 30,
+		"1tb":     1000 * 1000 * 1000 * 1000,
+		"0.5KiB":  512,
+		" 7 B ":   7,
+		"8388608": 8 << 20,
+	} {
+		size, err := system.ParseByteSize(value)
+		assert.Nil(err)
+		assert.Equal(expected, size)
+	}
+
+	for _, value := range []string{"", "MiB", "10XB", "1.2.3KB", "-1", "9999999PiB"} {
+		_, err := system.ParseByteSize(value)
+		assert.NotNil(err)
+	}
+}
+
+func TestEnvParsers(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	defer setEnv(t, map[string]string{
+		"T_INT":      " 42 ",
+		"T_BAD":      "forty",
+		"T_NEGATIVE": "-3",
+		"T_FLOAT":    "2.5",
+		"T_BOOL":     "yes",
+		"T_TIMEOUT":  "1m30s",
+		"T_URL":      "https://logs.example.com:8443/ingest",
+		"T_HOSTLESS": "logs.example.com",
+		"T_LIST":     "a| b ||c",
+		"T_MAP":      "team=core;tier=web",
+		"T_SIZE":     "10MiB",
+	})()
+
+	n, err := system.EnvInt64("T_INT")
+	assert.Nil(err)
+	assert.Equal(int64(42), n)
+	assert.Equal(42, system.EnvOrDefaultInt("T_INT", 1))
+	assert.Equal(1, system.EnvOrDefaultInt("T_BAD", 1))
+	assert.Equal(1, system.EnvOrDefaultInt("T_MISSING", 1))
+
+	_, err = system.EnvInt("T_BAD")
+	var varErr *system.VarError
+	assert.True(errors.As(err, &varErr))
+	assert.Equal("T_BAD", varErr.Name)
+	_, err = system.EnvInt("T_MISSING")
+	assert.True(errors.Is(err, system.ErrNotSet))
+
+	_, err = system.EnvUint("T_NEGATIVE")
+	assert.NotNil(err)
+	assert.Equal(uint64(9), system.EnvOrDefaultUint("T_NEGATIVE", 9))
+	assert.Equal(2.5, system.EnvOrDefaultFloat("T_FLOAT", 0))
+
+	_, err = system.EnvBool("T_BOOL")
+	assert.NotNil(err)
+	assert.True(system.EnvOrDefaultBool("T_BOOL", true))
+
+	assert.Equal(90*time.Second, system.EnvOrDefaultDuration("T_TIMEOUT", time.Second))
+	assert.Equal(time.Second, system.EnvOrDefaultDuration("T_INT", time.Second))
+
+	u, err := system.EnvURL("T_URL")
+	assert.Nil(err)
+	assert.Equal("logs.example.com:8443", u.Host)
+	_, err = system.EnvURL("T_HOSTLESS")
+	assert.NotNil(err)
+	assert.Equal(u, system.EnvOrDefaultURL("T_HOSTLESS", u))
+
+	list, err := system.EnvList("T_LIST", "|")
+	assert.Nil(err)
+	assert.Equal([]string{"a", "b", "c"}, list)
+	assert.Equal([]string{"x"}, system.EnvOrDefaultList("T_MISSING", ",", []string{"x"}))
+
+	m, err := system.EnvMap("T_MAP", ";")
+	assert.Nil(err)
+	assert.Equal(map[string]string{"team": "core", "tier": "web"}, m)
+	_, err = system.EnvMap("T_LIST", "|")
+	assert.NotNil(err)
+
+	assert.Equal(system.ByteSize(10<<20), system.EnvOrDefaultBytes("T_SIZE", 0))
+	assert.Equal(system.ByteSize(1), system.EnvOrDefaultBytes("T_BAD", 1))
+}
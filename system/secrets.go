@@ -0,0 +1,197 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretFileSuffix marks a variable holding the path of a file whose content
+// is the value of the variable without it, DB_PASSWORD_FILE for DB_PASSWORD
+const SecretFileSuffix = "_FILE"
+
+// SecretFile is a secret read from a file, like the ones Docker and
+// Kubernetes mount. The value is the file content with surrounding
+// whitespace trimmed. Unless AllowWorldReadable is set, a file anyone can
+// read is refused.
+type SecretFile struct {
+	Path               string
+	AllowWorldReadable bool
+
+	lock    sync.RWMutex
+	value   string
+	modTime time.Time
+	size    int64
+}
+
+// NewSecretFile reads the secret in path
+func NewSecretFile(path string) (*SecretFile, error) {
+	sf := &SecretFile{Path: path}
+	if _, err := sf.Reload(); err != nil {
+		return nil, err
+	}
+	return sf, nil
+}
+
+func (sf *SecretFile) Value() string {
+	sf.lock.RLock()
+	defer sf.lock.RUnlock()
+	return sf.value
+}
+
+// Reload reads the file again if its size or modification time has changed
+// and reports whether it did. Secrets mounted by Kubernetes are replaced
+// through a symlink, which is followed.
+func (sf *SecretFile) Reload() (bool, error) {
+	info, err := os.Stat(sf.Path)
+	if err != nil {
+		return false, err
+	}
+
+	if !sf.AllowWorldReadable && runtime.GOOS != "windows" && info.Mode().Perm()&0004 != 0 {
+		return false, fmt.Errorf("secret file %s is world readable (%s)", sf.Path, info.Mode().Perm())
+	}
+
+	sf.lock.RLock()
+	unchanged := !sf.modTime.IsZero() && info.ModTime().Equal(sf.modTime) && info.Size() == sf.size
+	sf.lock.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := ioutil.ReadFile(sf.Path)
+	if err != nil {
+		return false, err
+	}
+
+	sf.lock.Lock()
+	defer sf.lock.Unlock()
+	changed := sf.value != strings.TrimSpace(string(data))
+	sf.value = strings.TrimSpace(string(data))
+	sf.modTime = info.ModTime()
+	sf.size = info.Size()
+	return changed, nil
+}
+
+// Watch checks the file every interval and calls changed with the new value
+// whenever it's different. Failed reloads are passed to failed, which may
+// be nil. Call the returned function to stop watching.
+func (sf *SecretFile) Watch(interval time.Duration, changed func(value string), failed func(err error)) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if updated, err := sf.Reload(); err != nil {
+					if failed != nil {
+						failed(err)
+					}
+				} else if updated {
+					changed(sf.Value())
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// SecretsEnv resolves a variable that isn't set from the file named by the
+// same variable with SecretFileSuffix, so DB_PASSWORD can be given as
+// DB_PASSWORD_FILE=/run/secrets/db. Setting both is an error. Files are read
+// once, or every time they change if Reload is set. To have the package
+// level helpers like EnvRequired use it:
+//
+//	system.UseEnv(system.NewSecretsEnv(system.OsEnv{}))
+type SecretsEnv struct {
+	Env                Env
+	Reload             bool
+	AllowWorldReadable bool
+
+	lock  sync.Mutex
+	files map[string]*SecretFile
+}
+
+func NewSecretsEnv(env Env) *SecretsEnv {
+	return &SecretsEnv{
+		Env:   env,
+		files: make(map[string]*SecretFile),
+	}
+}
+
+// LookupEnv treats a secret file that can't be read as a missing variable,
+// Environment uses LookupEnvChecked to report the error instead
+func (s *SecretsEnv) LookupEnv(name string) (string, bool) {
+	value, found, err := s.LookupEnvChecked(name)
+	return value, found && err == nil
+}
+
+func (s *SecretsEnv) LookupEnvChecked(name string) (string, bool, error) {
+	value, found, err := lookupEnv(s.Env, name)
+	if err != nil {
+		return "", false, err
+	}
+
+	path, fromFile, err := lookupEnv(s.Env, name+SecretFileSuffix)
+	if err != nil || !fromFile {
+		return value, found, err
+	} else if found {
+		return "", false, fmt.Errorf("only one of %s and %s%s can be set", name, name, SecretFileSuffix)
+	}
+
+	sf, err := s.secretFile(path)
+	if err != nil {
+		return "", false, err
+	}
+	return sf.Value(), true, nil
+}
+
+func (s *SecretsEnv) secretFile(path string) (*SecretFile, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.files == nil {
+		s.files = make(map[string]*SecretFile)
+	}
+
+	if sf, cached := s.files[path]; cached {
+		if s.Reload {
+			if _, err := sf.Reload(); err != nil {
+				return nil, err
+			}
+		}
+		return sf, nil
+	}
+
+	sf := &SecretFile{Path: path, AllowWorldReadable: s.AllowWorldReadable}
+	if _, err := sf.Reload(); err != nil {
+		return nil, err
+	}
+	s.files[path] = sf
+	return sf, nil
+}
@@ -0,0 +1,136 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system_test
+
+import (
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/system"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeSecret(t *testing.T, path string, value string, mode os.FileMode) {
+	if err := ioutil.WriteFile(path, []byte(value), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSecretsEnv(t *testing.T) {
+	t.Parallel()
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "secrets")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	password := filepath.Join(dir, "password")
+	open := filepath.Join(dir, "open")
+	writeSecret(t, password, "  hunter2\n", 0600)
+	writeSecret(t, open, "visible", 0644)
+
+	secrets := system.NewSecretsEnv(system.MapEnv{
+		"DB_PASSWORD_FILE": password,
+		"OPEN_FILE":        open,
+		"BOTH":             "x",
+		"BOTH_FILE":        password,
+		"GONE_FILE":        filepath.Join(dir, "missing"),
+		"PLAIN":            "value",
+	})
+	env := system.NewEnvironment(secrets)
+
+	value, err := env.Required("DB_PASSWORD")
+	assert.Nil(err)
+	assert.Equal("hunter2", value)
+	assert.Equal("value", env.OrDefault("PLAIN", ""))
+
+	_, err = env.Required("OPEN")
+	assert.True(strings.Contains(err.Error(), "world readable"))
+	_, err = env.Required("BOTH")
+	assert.Equal("only one of BOTH and BOTH_FILE can be set", err.Error())
+	_, err = env.Required("GONE")
+	assert.True(os.IsNotExist(err))
+	_, found := secrets.LookupEnv("GONE")
+	assert.False(found)
+
+	var cfg struct {
+		Password string `env:"PASSWORD" required:"true"`
+		Open     string `env:"OPEN"`
+	}
+	err = system.NewEnvironment(secrets).BindPrefix("DB_", &cfg)
+	assert.Nil(err)
+	assert.Equal("hunter2", cfg.Password)
+	assert.NotNil(env.Bind(&cfg))
+
+	secrets.AllowWorldReadable = true
+	value, err = env.Required("OPEN")
+	assert.Nil(err)
+	assert.Equal("visible", value)
+}
+
+func TestSecretsEnv_Reload(t *testing.T) {
+	t.Parallel()
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "secrets")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	writeSecret(t, path, "first", 0600)
+	secrets := system.NewSecretsEnv(system.MapEnv{"TOKEN_FILE": path})
+
+	value, _ := secrets.LookupEnv("TOKEN")
+	assert.Equal("first", value)
+
+	writeSecret(t, path, "second-value", 0600)
+	value, _ = secrets.LookupEnv("TOKEN")
+	assert.Equal("first", value)
+
+	secrets.Reload = true
+	value, _ = secrets.LookupEnv("TOKEN")
+	assert.Equal("second-value", value)
+}
+
+func TestSecretFile_Watch(t *testing.T) {
+	t.Parallel()
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "secrets")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "key")
+	writeSecret(t, path, "old", 0600)
+	sf, err := system.NewSecretFile(path)
+	assert.Nil(err)
+	assert.Equal("old", sf.Value())
+
+	changes := make(chan string, 1)
+	stop := sf.Watch(5*time.Millisecond, func(value string) { changes <- value }, nil)
+	defer stop()
+
+	writeSecret(t, path, "rotated key", 0600)
+	select {
+	case value := <-changes:
+		assert.Equal("rotated key", value)
+	case <-time.After(5 * time.Second):
+		assert.Fail("no change seen")
+	}
+}
@@ -0,0 +1,355 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Struct tags declaring constraints on a setting, checked by Bind,
+// ConfigLoader and Validate
+const (
+	// TagMin and TagMax bound numbers and durations, or the length of
+	// strings, slices and maps
+	TagMin = "min"
+	TagMax = "max"
+	// TagOneOf lists the allowed values separated by spaces
+	TagOneOf = "oneof"
+	// TagPattern is a regular expression the whole value must match
+	TagPattern = "pattern"
+	// TagValidate lists checks separated by commas: url, file or dir
+	TagValidate = "validate"
+)
+
+// SecretNames are matched against setting names, ignoring case, like
+// logs.DefaultRedactedFields. Problems with a setting whose field or
+// variable name contains one of them don't quote its value, since the
+// report usually ends up in a startup log.
+var SecretNames = []string{"password", "passwd", "secret", "token", "authorization", "api_key", "apikey"}
+
+var byteSizeType = reflect.TypeOf(ByteSize(0))
+
+// Validate checks the constraints declared on every field of the struct cfg
+// points to, returning every violation together as VarErrors. Fields left at
+// their zero value are skipped, required is what catches those.
+//
+//	type Config struct {
+//		Port    int           `env:"PORT" min:"1" max:"65535"`
+//		Timeout time.Duration `env:"TIMEOUT" min:"1s"`
+//		Level   string        `env:"LEVEL" oneof:"debug info warn error"`
+//		Region  string        `env:"REGION" pattern:"[a-z]+-[a-z]+-[0-9]"`
+//		CA      string        `env:"CA" validate:"file"`
+//	}
+func Validate(cfg interface{}) error {
+	v, err := configStruct(cfg)
+	if err != nil {
+		return err
+	}
+
+	errs := validateSettings(settings("", v), nil, settingName, func(s *setting) bool {
+		return !s.value.IsZero()
+	})
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func settingName(s *setting) string {
+	if len(s.env) > 0 {
+		return s.env
+	}
+	return s.path
+}
+
+// validateSettings checks every setting that was set and isn't already in
+// errs, naming any problems with name
+func validateSettings(found []*setting, errs VarErrors, name func(s *setting) string, set func(s *setting) bool) VarErrors {
+	failed := make(map[string]bool, len(errs))
+	for _, err := range errs {
+		failed[err.Field] = true
+	}
+
+	for _, s := range found {
+		if failed[s.path] || s.detached() || !set(s) {
+			continue
+		}
+
+		if err := s.validate(); err != nil {
+			errs = append(errs, &VarError{Name: name(s), Field: s.path, Err: err})
+		}
+	}
+	return errs
+}
+
+// secret reports whether the setting's value should be kept out of errors
+func (s *setting) secret() bool {
+	field, env := strings.ToLower(s.field.Name), strings.ToLower(s.env)
+	for _, name := range SecretNames {
+		name = strings.ToLower(name)
+		if strings.Contains(field, name) || strings.Contains(env, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// show is how a value appears in an error, quoted if it's a string, or just
+// "value" for secrets
+func (s *setting) show(value interface{}) string {
+	if s.secret() {
+		return "value"
+	} else if str, ok := value.(string); ok {
+		return strconv.Quote(str)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func (s *setting) validate() error {
+	v := s.value
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	tag := s.field.Tag
+	if min, found := tag.Lookup(TagMin); found {
+		if err := checkBound(v, min, true, s.show); err != nil {
+			return err
+		}
+	}
+
+	if max, found := tag.Lookup(TagMax); found {
+		if err := checkBound(v, max, false, s.show); err != nil {
+			return err
+		}
+	}
+
+	if oneOf, found := tag.Lookup(TagOneOf); found {
+		allowed := strings.Fields(oneOf)
+		err := eachValue(v, func(value string) error {
+			for _, a := range allowed {
+				if value == a {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s must be one of %s", s.show(value), strings.Join(allowed, ", "))
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if pattern, found := tag.Lookup(TagPattern); found {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+
+		err = eachValue(v, func(value string) error {
+			if !re.MatchString(value) {
+				return fmt.Errorf("%s must match %s", s.show(value), pattern)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, check := range splitList(tag.Get(TagValidate), ",") {
+		if err := eachValue(v, checker(check, s.secret())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checker returns the named TagValidate check, which leaves the value out
+// of its errors if it's a secret
+func checker(name string, secret bool) func(value string) error {
+	switch name {
+	case "url":
+		return func(value string) error {
+			if _, err := parseURL(value); err != nil && secret {
+				return errors.New("value is not a valid URL")
+			} else if err != nil {
+				return fmt.Errorf("%q is not a valid URL: %w", value, err)
+			}
+			return nil
+		}
+
+	case "file", "dir":
+		return func(value string) error {
+			path := value
+			if secret {
+				path = "value"
+			}
+
+			info, err := os.Stat(value)
+			if err != nil {
+				var pathErr *os.PathError
+				if secret && errors.As(err, &pathErr) {
+					return fmt.Errorf("%s: %w", path, pathErr.Err)
+				}
+				return err
+			} else if name == "file" && info.IsDir() {
+				return fmt.Errorf("%s is a directory, not a file", path)
+			} else if name == "dir" && !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", path)
+			}
+			return nil
+		}
+	}
+
+	return func(string) error {
+		return fmt.Errorf("unknown check %s", name)
+	}
+}
+
+// eachValue calls check with the value, or each element of a slice, as a
+// string
+func eachValue(v reflect.Value, check func(value string) error) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < v.Len(); i++ {
+			if err := check(fmt.Sprintf("%v", v.Index(i).Interface())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(fmt.Sprintf("%v", v.Interface()))
+}
+
+// checkBound compares v to a minimum or maximum written in the same syntax
+// as the value itself, or to a length for strings, slices and maps
+func checkBound(v reflect.Value, bound string, isMin bool, show func(value interface{}) string) error {
+	var cmp int
+	var err error
+
+	switch {
+	case v.Type() == durationType:
+		var d time.Duration
+		d, err = parseDuration(bound)
+		cmp = compareInt(v.Int(), int64(d))
+
+	case v.Type() == byteSizeType:
+		var b ByteSize
+		b, err = ParseByteSize(bound)
+		cmp = compareInt(v.Int(), int64(b))
+
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(bound, 10, 64)
+		cmp = compareInt(v.Int(), n)
+
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uintptr:
+		var n uint64
+		n, err = strconv.ParseUint(bound, 10, 64)
+		cmp = compareUint(v.Uint(), n)
+
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(bound, 64)
+		cmp = compareFloat(v.Float(), f)
+
+	case v.Kind() == reflect.String || v.Kind() == reflect.Slice || v.Kind() == reflect.Map:
+		var n int64
+		n, err = strconv.ParseInt(bound, 10, 64)
+		if err == nil && isMin && int64(v.Len()) < n {
+			return fmt.Errorf("length must be at least %s", bound)
+		} else if err == nil && !isMin && int64(v.Len()) > n {
+			return fmt.Errorf("length must be at most %s", bound)
+		}
+
+	default:
+		return fmt.Errorf("%s cannot be bounded", v.Type())
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid bound %s: %w", bound, err)
+	} else if isMin && cmp < 0 {
+		return fmt.Errorf("%s must be at least %s", show(v.Interface()), bound)
+	} else if !isMin && cmp > 0 {
+		return fmt.Errorf("%s must be at most %s", show(v.Interface()), bound)
+	}
+	return nil
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Collect gathers the errors from reading several settings one at a time
+// into VarErrors, flattening any VarErrors among them and skipping nils. It
+// returns nil if they're all nil.
+func Collect(errs ...error) error {
+	var collected VarErrors
+	for _, err := range errs {
+		var varErrs VarErrors
+		var varErr *VarError
+
+		switch {
+		case err == nil:
+		case errors.As(err, &varErrs):
+			collected = append(collected, varErrs...)
+		case errors.As(err, &varErr):
+			collected = append(collected, varErr)
+		default:
+			collected = append(collected, &VarError{Err: err})
+		}
+	}
+
+	if len(collected) > 0 {
+		return collected
+	}
+	return nil
+}
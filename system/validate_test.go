@@ -0,0 +1,203 @@
+//
+//  Copyright 2020 Ray Cole
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+package system_test
+
+import (
+	"errors"
+	"github.com/threeguys/golang-toolkit/objects"
+	"github.com/threeguys/golang-toolkit/system"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type validatedConfig struct {
+	Port     int             `env:"PORT" min:"1" max:"65535"`
+	Workers  uint            `env:"WORKERS" max:"64"`
+	Ratio    float64         `env:"RATIO" min:"0" max:"1"`
+	Timeout  time.Duration   `env:"TIMEOUT" min:"1s" max:"1m"`
+	Buffer   system.ByteSize `env:"BUFFER" max:"1MiB"`
+	Level    string          `env:"LEVEL" oneof:"debug info warn error"`
+	Version  string          `env:"VERSION" pattern:"v[0-9]+"`
+	Name     string          `env:"NAME" min:"3"`
+	Peers    []string        `env:"PEERS" pattern:"[a-z]+:[0-9]+" max:"2"`
+	Endpoint string          `env:"ENDPOINT" validate:"url"`
+	CertFile string          `env:"CERT" validate:"file"`
+	DataDir  string          `env:"DATA" validate:"dir"`
+	Optional string          `env:"OPTIONAL" validate:"file"`
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	assert := objects.NewTestAssertions(t)
+	dir, err := ioutil.TempDir("", "validate")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	cert, err := ioutil.TempFile(dir, "cert")
+	assert.Nil(err)
+	assert.Nil(cert.Close())
+
+	valid := system.MapEnv{
+		"PORT":     "8080",
+		"WORKERS":  "8",
+		"RATIO":    "0.25",
+		"TIMEOUT":  "30s",
+		"BUFFER":   "64KiB",
+		"LEVEL":    "info",
+		"VERSION":  "v2",
+		"NAME":     "api",
+		"PEERS":    "a:1,b:2",
+		"ENDPOINT": "https://example.com",
+		"CERT":     cert.Name(),
+		"DATA":     dir,
+	}
+	var cfg validatedConfig
+	assert.Nil(system.NewEnvironment(valid).Bind(&cfg))
+	assert.Nil(system.Validate(&cfg))
+
+	invalid := system.MapEnv{
+		"PORT":     "0",
+		"WORKERS":  "65",
+		"RATIO":    "1.5",
+		"TIMEOUT":  "500ms",
+		"BUFFER":   "2MiB",
+		"LEVEL":    "loud",
+		"VERSION":  "2",
+		"NAME":     "ab",
+		"PEERS":    "a:1,B:2",
+		"ENDPOINT": "example.com",
+		"CERT":     dir,
+		"DATA":     cert.Name(),
+	}
+	cfg = validatedConfig{}
+	err = system.NewEnvironment(invalid).Bind(&cfg)
+
+	var errs system.VarErrors
+	assert.True(errors.As(err, &errs))
+	messages := make(map[string]string)
+	for _, e := range errs {
+		messages[e.Name] = e.Err.Error()
+	}
+	assert.Equal(map[string]string{
+		"PORT":     "0 must be at least 1",
+		"WORKERS":  "65 must be at most 64",
+		"RATIO":    "1.5 must be at most 1",
+		"TIMEOUT":  "500ms must be at least 1s",
+		"BUFFER":   "2097152 must be at most 1MiB",
+		"LEVEL":    "\"loud\" must be one of debug, info, warn, error",
+		"VERSION":  "\"2\" must match v[0-9]+",
+		"NAME":     "length must be at least 3",
+		"PEERS":    "\"B:2\" must match [a-z]+:[0-9]+",
+		"ENDPOINT": "\"example.com\" is not a valid URL: URL must include a scheme and host",
+		"CERT":     dir + " is a directory, not a file",
+		"DATA":     cert.Name() + " is not a directory",
+	}, messages)
+}
+
+func TestMapRequired_AllMissing(t *testing.T) {
+	t.Parallel()
+	assert := objects.NewTestAssertions(t)
+	env := system.NewEnvironment(system.MapEnv{"HOST": "db"})
+
+	_, err := env.MapRequired([]string{"USER", "HOST", "PASSWORD"})
+	var errs system.VarErrors
+	assert.True(errors.As(err, &errs))
+	assert.Equal(2, len(errs))
+	assert.Equal("USER", errs[0].Name)
+	assert.Equal("PASSWORD", errs[1].Name)
+	assert.True(errors.Is(err, system.ErrNotSet))
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+	assert := objects.NewTestAssertions(t)
+	env := system.NewEnvironment(system.MapEnv{"PORT": "x", "HOST": "db"})
+
+	_, portErr := env.Int("PORT")
+	_, hostErr := env.Required("HOST")
+	_, keysErr := env.MapRequired([]string{"KEY", "SECRET"})
+	assert.Nil(system.Collect(hostErr, nil))
+
+	err := system.Collect(portErr, hostErr, keysErr, errors.New("disk full"))
+	var errs system.VarErrors
+	assert.True(errors.As(err, &errs))
+	assert.Equal(4, len(errs))
+	assert.Equal("disk full", errs[3].Error())
+
+	assert.Equal("4 configuration problems:\n"+
+		"  PORT    strconv.ParseInt: parsing \"x\": invalid syntax\n"+
+		"  KEY     required but not set\n"+
+		"  SECRET  required but not set\n"+
+		"  -       disk full\n", errs.Report())
+}
+
+func TestConfigLoader_Validation(t *testing.T) {
+	t.Parallel()
+	assert := objects.NewTestAssertions(t)
+
+	var cfg struct {
+		Port  int    `env:"PORT" default:"8080" max:"8000"`
+		Level string `env:"LEVEL" oneof:"debug info"`
+		Retry int    `env:"RETRY" min:"1"`
+	}
+	loader := &system.ConfigLoader{Env: system.MapEnv{"LEVEL": "trace"}}
+	_, err := loader.Load(&cfg)
+
+	var errs system.VarErrors
+	assert.True(errors.As(err, &errs))
+	assert.Equal(2, len(errs))
+	assert.Equal("PORT", errs[0].Name)
+	assert.Equal("LEVEL", errs[1].Name)
+	assert.Equal("1 configuration problem:\n  LEVEL  \"trace\" must be one of debug, info (Level)\n",
+		system.VarErrors{errs[1]}.Report())
+}
+
+func TestValidate_Secrets(t *testing.T) {
+	assert := objects.NewTestAssertions(t)
+	var cfg struct {
+		Password string `env:"DB_PASSWORD" pattern:"[a-z]{12,}" oneof:"correcthorse batterystaple"`
+		Token    string `env:"API_TOKEN" validate:"url"`
+		PinCode  int    `env:"SECRET_PIN" min:"1000"`
+		Timeout  int    `env:"TIMEOUT"`
+	}
+
+	env := system.NewEnvironment(system.MapEnv{
+		"DB_PASSWORD": "hunter2",
+		"API_TOKEN":   "abc.def",
+		"SECRET_PIN":  "42",
+	})
+	err := env.Bind(&cfg)
+	assert.NotNil(err)
+
+	report := err.(system.VarErrors).Report()
+	assert.Equal("3 configuration problems:\n"+
+		"  DB_PASSWORD  value must be one of correcthorse, batterystaple (Password)\n"+
+		"  API_TOKEN    value is not a valid URL (Token)\n"+
+		"  SECRET_PIN   value must be at least 1000 (PinCode)\n", report)
+
+	env = system.NewEnvironment(system.MapEnv{"SECRET_PIN": "12ab", "TIMEOUT": "12ab"})
+	err = env.Bind(&cfg)
+	assert.NotNil(err)
+	assert.Equal("2 environment problems:\n"+
+		"\tSECRET_PIN: value is not a valid int\n"+
+		"\tTIMEOUT: strconv.ParseInt: parsing \"12ab\": invalid syntax", err.Error())
+
+	var numErr *strconv.NumError
+	assert.True(errors.As(err.(system.VarErrors)[0], &numErr))
+}